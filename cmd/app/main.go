@@ -2,13 +2,15 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"os"
 	"syscall"
 	"time"
 
 	"github.com/kaedwen/trade/pkg/app"
 	"github.com/kaedwen/trade/pkg/app/utils"
+	"github.com/kaedwen/trade/pkg/cli"
 )
 
 func main() {
@@ -17,10 +19,23 @@ func main() {
 
 	go utils.SigWatch(end, time.Second*5, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 
+	if len(os.Args) > 1 {
+		if err := cli.Run(ctx, os.Args[1:]); err != nil {
+			slog.Error("command failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	app, err := app.NewApplication()
 	if err != nil {
-		log.Fatal("failed to create application", err)
+		slog.Error("failed to create application", "error", err)
+		os.Exit(1)
 	}
 
-	app.Run(ctx)
+	// a shutdown during login is no failure
+	if err := app.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		slog.Error("service failed", "error", err)
+		os.Exit(1)
+	}
 }
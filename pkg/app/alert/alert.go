@@ -0,0 +1,164 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+const stateName = "alerts"
+
+// Evaluator checks the configured rules after every fetch. A level rule fires
+// once when its condition becomes true and is re-armed only after the value
+// recovered beyond the hysteresis. The cooldown limits how often a rule fires
+// at all. The state is kept in the store to survive restarts.
+type Evaluator struct {
+	bus   event.Bus
+	store store.Store
+	rules []*rule
+
+	mu    sync.Mutex
+	state map[string]ruleState
+}
+
+type ruleState struct {
+	Active    bool      `json:"active"`
+	LastFired time.Time `json:"lastFired"`
+}
+
+func NewEvaluator(cfg *config.Config, bus event.Bus, st store.Store) (*Evaluator, error) {
+	e := &Evaluator{
+		bus:   bus,
+		store: st,
+		state: make(map[string]ruleState),
+	}
+
+	var err error
+	if e.rules, err = newRules(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := st.Load(stateName, &e.state); err != nil && !errors.Is(err, store.ErrNoState) {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func newRules(cfg *config.Config) ([]*rule, error) {
+	var rules []*rule
+	for i, a := range cfg.Alerts {
+		r, err := newRule(i, a)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// Reload replaces the rules, the state of rules keeping their name is kept.
+func (e *Evaluator) Reload(cfg *config.Config) error {
+	rules, err := newRules(cfg)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.rules = rules
+	return nil
+}
+
+func (e *Evaluator) Subscribe(ctx context.Context) {
+	e.bus.Subscribe(ctx, func(ev event.Event) {
+		var err error
+
+		switch data := ev.Data.(type) {
+		case *event.Snapshot:
+			err = e.snapshot(data)
+		case event.TransactionArrival:
+			err = e.transaction(&data)
+		}
+
+		if err != nil {
+			slog.Error("failed to evaluate alerts", "error", err)
+		}
+	}, event.SnapshotFetched, event.NewTransaction)
+}
+
+func (e *Evaluator) snapshot(s *event.Snapshot) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, r := range e.rules {
+		obs, err := r.observe(s)
+		if err != nil {
+			return err
+		}
+
+		for _, o := range obs {
+			e.apply(r, o)
+		}
+	}
+
+	return e.store.Save(stateName, e.state)
+}
+
+func (e *Evaluator) transaction(t *event.TransactionArrival) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, r := range e.rules {
+		if o, ok := r.match(t); ok {
+			e.fire(r, o, e.state[r.Name+"/"+o.key])
+		}
+	}
+
+	return e.store.Save(stateName, e.state)
+}
+
+func (e *Evaluator) apply(r *rule, o observation) {
+	id := r.Name + "/" + o.key
+	s := e.state[id]
+
+	switch {
+	case o.triggered && !s.Active:
+		e.fire(r, o, s)
+	case s.Active && o.cleared:
+		s.Active = false
+		e.state[id] = s
+		e.publish(event.AlertResolved, r, o)
+	}
+}
+
+// fire publishes the alert unless the rule is cooling down.
+func (e *Evaluator) fire(r *rule, o observation, s ruleState) bool {
+	if time.Since(s.LastFired) < r.cooldown() {
+		return false
+	}
+
+	e.state[r.Name+"/"+o.key] = ruleState{Active: true, LastFired: time.Now()}
+	e.publish(event.AlertTriggered, r, o)
+
+	return true
+}
+
+func (e *Evaluator) publish(t event.Type, r *rule, o observation) {
+	e.bus.Publish(t, event.Alert{
+		Rule:      r.Name,
+		Key:       o.key,
+		Severity:  r.severity,
+		Message:   o.message,
+		Value:     o.value,
+		Threshold: r.Threshold,
+	})
+}
@@ -0,0 +1,174 @@
+package alert
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/config"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+const (
+	KindBalanceBelow             = "balanceBelow"
+	KindCreditUtilisationAbove   = "creditUtilisationAbove"
+	KindPositionDrop             = "positionDrop"
+	KindIncomingTransactionAbove = "incomingTransactionAbove"
+	KindTransactionMatch         = "transactionMatch"
+)
+
+type rule struct {
+	config.Alert
+	severity event.Severity
+	pattern  *regexp.Regexp
+}
+
+// observation is the value of a level rule for one account or position.
+type observation struct {
+	key       string
+	value     float64
+	triggered bool
+	cleared   bool
+	message   string
+}
+
+func newRule(i int, a config.Alert) (*rule, error) {
+	r := &rule{Alert: a, severity: event.Severity(strings.ToLower(a.Severity))}
+
+	if len(r.Name) == 0 {
+		r.Name = fmt.Sprintf("%s-%d", r.Kind, i)
+	}
+
+	switch r.severity {
+	case "":
+		r.severity = event.SeverityWarning
+	case event.SeverityInfo, event.SeverityWarning, event.SeverityCritical:
+	default:
+		return nil, fmt.Errorf("alert %s has invalid severity %q", r.Name, a.Severity)
+	}
+
+	switch r.Kind {
+	case KindBalanceBelow, KindCreditUtilisationAbove:
+		if len(r.Account) == 0 {
+			return nil, fmt.Errorf("alert %s requires an account", r.Name)
+		}
+	case KindPositionDrop, KindIncomingTransactionAbove:
+	case KindTransactionMatch:
+		var err error
+		if r.pattern, err = regexp.Compile(r.Pattern); err != nil {
+			return nil, fmt.Errorf("alert %s has invalid pattern - %w", r.Name, err)
+		}
+	default:
+		return nil, fmt.Errorf("alert %s has unknown kind %q", r.Name, r.Kind)
+	}
+
+	return r, nil
+}
+
+func (r *rule) cooldown() time.Duration {
+	return r.Cooldown.Duration
+}
+
+// observe evaluates level rules against a snapshot.
+func (r *rule) observe(s *event.Snapshot) ([]observation, error) {
+	var obs []observation
+
+	switch r.Kind {
+	case KindBalanceBelow, KindCreditUtilisationAbove:
+		for _, v := range s.Balances.Values {
+			if v.ProductType == model.ProductTypeDepot || v.ProductType == model.ProductTypeCard {
+				continue
+			}
+
+			b, err := v.AccountBalance()
+			if err != nil {
+				return nil, err
+			}
+
+			if r.Account != b.AccountID && r.Account != b.Account.AccountDisplayId && r.Account != b.Account.IBAN {
+				continue
+			}
+
+			if r.Kind == KindBalanceBelow {
+				obs = append(obs, r.below(b.AccountID, b.Balance.Value,
+					fmt.Sprintf("balance of %s is %.2f %s", b.Account.AccountDisplayId, b.Balance.Value, b.Balance.Unit)))
+				continue
+			}
+
+			if b.Account.CreditLimit.Value <= 0 {
+				continue
+			}
+
+			utilisation := math.Max(0, -b.Balance.Value) / b.Account.CreditLimit.Value * 100
+			obs = append(obs, r.above(b.AccountID, utilisation,
+				fmt.Sprintf("credit limit of %s utilised by %.1f%%", b.Account.AccountDisplayId, utilisation)))
+		}
+	case KindPositionDrop:
+		for _, p := range s.Positions {
+			if len(r.Instrument) > 0 && r.Instrument != p.InstrumentId && r.Instrument != p.Wkn && (p.Instrument == nil || r.Instrument != p.Instrument.Isin) {
+				continue
+			}
+
+			name := p.Wkn
+			if p.Instrument != nil && len(p.Instrument.Name) > 0 {
+				name = p.Instrument.Name
+			}
+
+			drop := -p.ProfitLossPrevDayRel
+			obs = append(obs, r.above(p.DepotId+"/"+p.InstrumentId, drop,
+				fmt.Sprintf("%s down %.2f%% since yesterday", name, drop)))
+		}
+	}
+
+	return obs, nil
+}
+
+// match evaluates transaction rules against a new transaction.
+func (r *rule) match(t *event.TransactionArrival) (observation, bool) {
+	if len(r.Account) > 0 && r.Account != t.AccountId {
+		return observation{}, false
+	}
+
+	amount := t.Transaction.Amount
+	switch r.Kind {
+	case KindIncomingTransactionAbove:
+		if amount.Value <= r.Threshold {
+			return observation{}, false
+		}
+	case KindTransactionMatch:
+		if !r.pattern.MatchString(transactionText(&t.Transaction)) {
+			return observation{}, false
+		}
+	default:
+		return observation{}, false
+	}
+
+	return observation{
+		key:       t.AccountId,
+		value:     amount.Value,
+		triggered: true,
+		message:   fmt.Sprintf("transaction of %.2f %s on %s: %s", amount.Value, amount.Unit, t.AccountId, transactionText(&t.Transaction)),
+	}, true
+}
+
+func (r *rule) below(key string, value float64, message string) observation {
+	return observation{key, value, value < r.Threshold, value >= r.Threshold+r.Hysteresis, message}
+}
+
+func (r *rule) above(key string, value float64, message string) observation {
+	return observation{key, value, value > r.Threshold, value <= r.Threshold-r.Hysteresis, message}
+}
+
+func transactionText(t *model.Transaction) string {
+	parts := []string{t.TransactionType.Text, t.RemittanceInfo}
+	for _, p := range []*model.TransactionParty{t.Remitter, t.Deptor, t.Creditor} {
+		if p != nil {
+			parts = append(parts, p.HolderName)
+		}
+	}
+
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
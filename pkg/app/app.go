@@ -2,98 +2,458 @@ package app
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/kaedwen/trade/pkg/app/alert"
+	"github.com/kaedwen/trade/pkg/app/banking"
+	"github.com/kaedwen/trade/pkg/app/brokerage"
+	"github.com/kaedwen/trade/pkg/app/cache"
+	"github.com/kaedwen/trade/pkg/app/change"
 	"github.com/kaedwen/trade/pkg/app/client"
-	api_error "github.com/kaedwen/trade/pkg/app/error"
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/instance"
+	"github.com/kaedwen/trade/pkg/app/lockout"
+	"github.com/kaedwen/trade/pkg/app/logging"
+	"github.com/kaedwen/trade/pkg/app/notify"
+	"github.com/kaedwen/trade/pkg/app/postbox"
+	"github.com/kaedwen/trade/pkg/app/scheduler"
+	"github.com/kaedwen/trade/pkg/app/server"
 	"github.com/kaedwen/trade/pkg/app/session"
-	"github.com/kaedwen/trade/pkg/app/utils"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/app/systemd"
+	"github.com/kaedwen/trade/pkg/app/tan"
 	"github.com/kaedwen/trade/pkg/config"
 	"github.com/kaedwen/trade/pkg/model"
 )
 
-const (
-	ApiAccountPath = "/banking/clients/user/v2/accounts/balances"
-)
-
 type Application struct {
 	session.Session
-	cfg *config.Config
+	cfg       *config.Config
+	approver  tan.Approver
+	banking   banking.Banking
+	brokerage brokerage.Brokerage
+	postbox   postbox.Postbox
+	bus       event.Bus
+	store     store.Store
+	detector  *change.Detector
+	archiver  *postbox.Archiver
+	cache     *cache.Cache
+	events    *event.Ring
+	scheduler scheduler.Scheduler
+	relay     *tan.Relay
+	// mu guards client, lockout, instance and loginStore, which Login sets
+	// while the control socket is already served
+	mu       sync.Mutex
+	client   client.Client
+	lockout  *lockout.Guard
+	instance *instance.Lock
+	// loginStore is the store opened for the login lock outside of Run
+	loginStore store.Store
+	alerts     *alert.Evaluator
+	notify     *notify.Dispatcher
+	pending    atomic.Pointer[event.TanChallenge]
+	// activeSince is the time the session last became active
+	activeSince atomic.Pointer[time.Time]
+	reauth      atomic.Bool
+	// retry wakes a relogin loop waiting for its next attempt
+	retry     chan struct{}
+	relogging atomic.Bool
+}
+
+var (
+	ErrNotLoggedIn    = errors.New("not logged in")
+	ErrReauthRunning  = errors.New("re-authentication already running")
+	ErrArguments      = errors.New("wrong number of arguments")
+	ErrForeignUrl     = errors.New("request does not target the configured api")
+	ErrDaemonInactive = errors.New("session of running daemon is not active")
+)
+
+const lockFile = "trade.lock"
+
+// eventBuffer is the number of events kept for clients resuming the stream.
+const eventBuffer = 256
+
+// streamedEvents are the events served on the local event stream, snapshots
+// are left out as they are available on the read-only endpoints.
+var streamedEvents = []event.Type{
+	event.BalanceChanged, event.NewTransaction, event.PositionAdded, event.PositionRemoved,
+	event.QuantityChanged, event.PriceMoved, event.DocumentArrived, event.OrderStateChanged, event.AlertTriggered,
+	event.AlertResolved, event.TanRequired, event.SessionChanged, event.LoginLocked,
 }
 
-func NewApplication() (*Application, error) {
+type Option func(*Application)
+
+// WithApprover replaces the default approver waiting for push TAN challenges.
+func WithApprover(approver tan.Approver) Option {
+	return func(a *Application) {
+		a.approver = approver
+	}
+}
+
+func NewApplication(opt ...Option) (*Application, error) {
 	cfg, err := config.NewConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config - %w", err)
 	}
 
-	return &Application{session.NewSession(cfg), cfg}, nil
+	if err := logging.Setup(cfg); err != nil {
+		return nil, err
+	}
+
+	a := &Application{
+		cfg:      cfg,
+		approver: tan.NewSignalApprover(30 * time.Second),
+		bus:      event.NewBus(),
+		retry:    make(chan struct{}, 1),
+	}
+	a.cache = cache.NewCache(a.bus)
+	a.events = event.NewRing(eventBuffer)
+
+	for _, o := range opt {
+		o(a)
+	}
+
+	a.relay = tan.NewRelay(a.approver)
+	a.approver = a.announce(a.relay)
+
+	a.Session = session.NewSession(cfg, a.approver)
+	a.Observe(func(from, to session.State) {
+		if to == session.StateActive {
+			now := time.Now()
+			a.activeSince.Store(&now)
+		}
+		a.bus.Publish(event.SessionChanged, event.SessionState{State: string(to)})
+	})
+	a.banking = banking.NewBanking(cfg, a.Session)
+	a.brokerage = brokerage.NewBrokerage(cfg, a.Session)
+	a.postbox = postbox.NewPostbox(cfg, a.Session)
+
+	return a, nil
 }
 
-func (a *Application) Run(ctx context.Context) error {
-	ctx, err := client.NewClient(a.cfg).Connect(ctx)
+func (a *Application) Banking() banking.Banking {
+	return a.banking
+}
+
+func (a *Application) Brokerage() brokerage.Brokerage {
+	return a.brokerage
+}
+
+func (a *Application) Postbox() postbox.Postbox {
+	return a.postbox
+}
+
+func (a *Application) Approver() tan.Approver {
+	return a.approver
+}
+
+// announce publishes a TanRequired event before waiting for the approval, so
+// notifications reach the user also on headless startups. A second challenge
+// is refused while one is pending.
+func (a *Application) announce(approver tan.Approver) tan.Approver {
+	return tan.ApproverFunc(func(ctx context.Context, c *tan.Challenge) (string, error) {
+		challenge := event.TanChallenge{Id: c.Id, Type: c.Type, Challenge: c.Challenge}
+		if !a.pending.CompareAndSwap(nil, &challenge) {
+			return "", tan.ErrBusy
+		}
+		defer a.pending.Store(nil)
+
+		a.bus.Publish(event.TanRequired, challenge)
+		return approver.Approve(ctx, c)
+	})
+}
+
+// Login runs the full oauth and session TAN flow and returns a context
+// carrying the authenticated client.
+func (a *Application) Login(ctx context.Context) (context.Context, error) {
+	c, err := a.prepareLogin()
 	if err != nil {
+		return nil, err
+	}
+
+	return a.Session.Login(ctx, c)
+}
+
+// prepareLogin sets up the login lock, takes the instance lock and creates
+// the client, which is kept across logins.
+func (a *Application) prepareLogin() (client.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.lockout == nil {
+		st := a.store
+		if st == nil {
+			var err error
+			if st, err = store.Open(a.cfg.Store.Directory); err != nil {
+				return nil, fmt.Errorf("failed to open store - %w", err)
+			}
+			a.loginStore = st
+		}
+		a.lockout = lockout.NewGuard(st, a.bus)
+	}
+
+	// a second instance would start its own TAN flow and invalidate the
+	// session of the first one
+	if a.instance == nil {
+		l, err := instance.Acquire(filepath.Join(a.cfg.Store.Directory, lockFile))
+		if err != nil {
+			return nil, err
+		}
+		a.instance = l
+	}
+
+	if a.client == nil {
+		a.client = a.lockout.Wrap(client.NewClient(a.cfg))
+	}
+
+	return a.client, nil
+}
+
+// Close releases the instance lock and closes the store opened by Login.
+func (a *Application) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.instance != nil {
+		a.instance.Release()
+		a.instance = nil
+	}
+
+	if a.loginStore != nil {
+		err := a.loginStore.Close()
+		a.loginStore = nil
 		return err
 	}
 
-	if err := a.Init(ctx); err != nil {
+	return nil
+}
+
+// loggedIn returns the client and the login lock, nil before the first
+// login attempt.
+func (a *Application) loggedIn() (client.Client, *lockout.Guard) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.client, a.lockout
+}
+
+// UnlockLogin clears a login lock after rejected credentials and logs in
+// again if there is no active session.
+func (a *Application) UnlockLogin(ctx context.Context) error {
+	c, guard := a.loggedIn()
+	if guard == nil {
+		return ErrNotLoggedIn
+	}
+
+	if err := guard.Unlock(); err != nil {
 		return err
 	}
 
-	ctx, err = client.FromContext(ctx).OAuthSecondFlow(ctx)
-	if err != nil {
+	if c != nil && !a.Active() {
+		a.retryLogin(ctx)
+	}
+
+	return nil
+}
+
+// Reauth repeats the login on the existing client, which is updated in place
+// so contexts handed out before keep working. Concurrent calls are refused.
+func (a *Application) Reauth(ctx context.Context) error {
+	c, _ := a.loggedIn()
+	if c == nil {
+		return ErrNotLoggedIn
+	}
+
+	if !a.reauth.CompareAndSwap(false, true) {
+		return ErrReauthRunning
+	}
+	defer a.reauth.Store(false)
+
+	slog.Info("re-authenticate session")
+
+	_, err := a.Session.Login(ctx, c)
+	return err
+}
+
+// startReauth runs Reauth in the background, the TAN approval may take long.
+func (a *Application) startReauth(ctx context.Context) error {
+	if c, _ := a.loggedIn(); c == nil {
+		return ErrNotLoggedIn
+	}
+
+	if a.reauth.Load() {
+		return ErrReauthRunning
+	}
+
+	go func() {
+		if err := a.Reauth(ctx); err != nil {
+			slog.Error("re-authentication failed", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+func (a *Application) Run(ctx context.Context) error {
+	if err := server.CheckConfig(a.cfg); err != nil {
 		return err
 	}
 
-	t := time.NewTicker(time.Minute)
+	st, err := store.Open(a.cfg.Store.Directory)
+	if err != nil {
+		return fmt.Errorf("failed to open store - %w", err)
+	}
+	defer st.Close()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case <-t.C:
-			cctx, cancel := context.WithTimeout(ctx, time.Minute)
-			defer cancel()
+	a.store = st
 
-			if err := a.fetchAccount(cctx); err != nil {
-				log.Println("failed to fetch account - %w", err)
-			}
+	// the instance lock comes first, only its holder may replace the sockets
+	// of a running instance
+	c, err := a.prepareLogin()
+	if err != nil {
+		return err
+	}
+
+	defer a.Close()
+	defer systemd.Notify(systemd.Stopping)
+	a.detector = change.NewDetector(a.cfg, a.bus, st)
+
+	if len(a.cfg.Postbox.Directory) > 0 {
+		a.archiver, err = postbox.NewArchiver(a.cfg, a.postbox)
+		if err != nil {
+			return err
 		}
 	}
+
+	a.alerts, err = alert.NewEvaluator(a.cfg, a.bus, st)
+	if err != nil {
+		return fmt.Errorf("failed to load alerts - %w", err)
+	}
+
+	a.notify, err = notify.NewDispatcher(a.cfg, a.bus, st)
+	if err != nil {
+		return fmt.Errorf("failed to setup notifications - %w", err)
+	}
+
+	a.logEvents(ctx)
+	a.storeEvents(ctx)
+	a.metricEvents(ctx)
+	a.cache.Subscribe(ctx)
+	a.events.Subscribe(ctx, a.bus, streamedEvents...)
+	a.alerts.Subscribe(ctx)
+	a.notify.Subscribe(ctx)
+
+	s := scheduler.NewScheduler()
+	a.scheduler = s
+	s.Add("accounts", a.cfg.Jobs.Accounts.Duration, a.guard(a.fetchAccount))
+	s.Add("orders", a.cfg.Jobs.Orders.Duration, a.guard(brokerage.NewOrderTracker(a.brokerage, a.bus).Poll))
+	s.Add("documents", a.cfg.Jobs.Documents.Duration, a.guard(a.fetchDocuments))
+
+	// the sockets are up before login, so the TAN can be answered and the
+	// probes report the login; the client is logged in in place
+	base := ctx
+	ctx = client.NewContext(ctx, c)
+	a.notifySystemd()
+	go a.watchdog(base)
+	go func() {
+		if err := a.controlServer(base).Serve(base); err != nil {
+			slog.Error("control socket failed", "error", err)
+		}
+	}()
+
+	go func() {
+		// orders from the API wait for an explicit answer over the control
+		// socket, they never go ahead on a timeout
+		confirm := a.announce(tan.ApproverFunc(a.relay.Confirm))
+		if err := server.NewServer(a.cfg, a.brokerage, confirm,
+			server.WithCache(a.cache),
+			server.WithStatus(func() any { return a.Status() }),
+			server.WithChecks(a.Checks),
+			server.WithEvents(a.events),
+			server.WithStore(a.store),
+			server.WithReauth(func() error { return a.startReauth(base) }),
+		).Serve(ctx); err != nil {
+			slog.Error("http server failed", "error", err)
+		}
+	}()
+
+	// jobs only run with an active session, a lost or failed session is
+	// recovered by logging in again
+	s.Hold(true)
+	a.Observe(func(from, to session.State) {
+		s.Hold(to != session.StateActive)
+		if to == session.StateExpired || to == session.StateDisconnected {
+			go a.relogin(base)
+		}
+	})
+
+	// a failed first login keeps the daemon running and is retried with a
+	// backoff, a restart would only repeat the password grant and TAN
+	go a.relogin(base)
+
+	s.Run(ctx)
+
+	return nil
 }
 
+// fetchAccount fetches balances, positions and latest transactions and hands
+// them to change detection, sinks subscribe to the resulting events.
 func (a *Application) fetchAccount(ctx context.Context) error {
-	log.Println("running account fetch")
+	slog.Info("running account fetch")
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.cfg.ApiAddress.JoinPath(ApiAccountPath).String(), http.NoBody)
+	snapshot := &event.Snapshot{
+		Time:         time.Now(),
+		Transactions: make(map[string][]model.Transaction),
+	}
+
+	var err error
+	snapshot.Balances, err = a.banking.AllBalances(ctx)
 	if err != nil {
 		return err
 	}
-	req.Header.Add("x-http-request-info", a.NewRequestInfo())
 
-	resp, err := client.FromContext(ctx).Do(req)
+	for _, v := range snapshot.Balances.Values {
+		switch v.ProductType {
+		case model.ProductTypeDepot:
+			p, err := a.brokerage.Positions(ctx, v.ProductId)
+			if err != nil {
+				return err
+			}
+			snapshot.Positions = append(snapshot.Positions, p...)
+		case model.ProductTypeCard:
+		default:
+			t, err := a.banking.Transactions(ctx, v.ProductId, a.cfg.Events.Transactions)
+			if err != nil {
+				return err
+			}
+			snapshot.Transactions[v.ProductId] = t
+		}
+	}
+
+	return a.detector.Snapshot(snapshot)
+}
+
+func (a *Application) fetchDocuments(ctx context.Context) error {
+	documents, err := a.postbox.Documents(ctx)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Println(resp.StatusCode, utils.ReadAllString(resp.Body))
-		return api_error.ErrApiBadStatus
-	}
+	a.cache.SetDocuments(time.Now(), documents)
 
-	var data model.AccountBalanceResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+	if err := a.detector.Documents(documents); err != nil {
 		return err
 	}
 
-	for _, v := range data.Values {
-		log.Println(v.AccountID, v.BalanceEUR, v.AvailableCashAmountEUR)
+	if a.archiver == nil {
+		return nil
 	}
 
-	return nil
+	return a.archiver.Archive(ctx, documents)
 }
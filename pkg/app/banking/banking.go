@@ -0,0 +1,92 @@
+package banking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/kaedwen/trade/pkg/app/client"
+	"github.com/kaedwen/trade/pkg/app/session"
+	"github.com/kaedwen/trade/pkg/config"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+const (
+	ApiAccountPath      = "/banking/clients/user/v2/accounts/balances"
+	ApiTransactionsPath = "/banking/v1/accounts/%s/transactions"
+	ApiAllBalancesPath  = "/reports/participants/user/v1/allbalances"
+)
+
+type Banking interface {
+	AccountBalances(context.Context) ([]model.AccountBalance, error)
+	AllBalances(context.Context) (*model.AllBalancesResponse, error)
+	Transactions(context.Context, string, int) ([]model.Transaction, error)
+}
+
+type banking struct {
+	session.Session
+	cfg *config.Config
+}
+
+func NewBanking(cfg *config.Config, s session.Session) Banking {
+	return &banking{s, cfg}
+}
+
+func (b *banking) AccountBalances(ctx context.Context) ([]model.AccountBalance, error) {
+	var data model.AccountBalanceResponse
+	if err := b.get(ctx, &data, nil, ApiAccountPath); err != nil {
+		return nil, err
+	}
+
+	return data.Values, nil
+}
+
+// AllBalances fetches accounts, depots and cards with their balances in a
+// single request.
+func (b *banking) AllBalances(ctx context.Context) (*model.AllBalancesResponse, error) {
+	var data model.AllBalancesResponse
+	if err := b.get(ctx, &data, nil, ApiAllBalancesPath); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// Transactions returns the latest booked transactions of the account.
+func (b *banking) Transactions(ctx context.Context, accountId string, count int) ([]model.Transaction, error) {
+	var data model.TransactionsResponse
+	if err := b.get(ctx, &data, url.Values{
+		"transactionState": {"BOOKED"},
+		"paging-count":     {strconv.Itoa(count)},
+	}, ApiTransactionsPath, accountId); err != nil {
+		return nil, err
+	}
+
+	return data.Values, nil
+}
+
+func (b *banking) get(ctx context.Context, out any, query url.Values, tpl string, args ...any) error {
+	u := b.cfg.ApiAddress.JoinPath(fmt.Sprintf(tpl, args...))
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("x-http-request-info", b.NewRequestInfo())
+
+	resp, err := client.FromContext(ctx).Do(req, client.WithEndpoint(tpl))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return client.BadStatus(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,290 @@
+package brokerage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/kaedwen/trade/pkg/app/client"
+	"github.com/kaedwen/trade/pkg/app/session"
+	"github.com/kaedwen/trade/pkg/app/tan"
+	"github.com/kaedwen/trade/pkg/config"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+const (
+	ApiDepotsPath           = "/brokerage/clients/user/v3/depots"
+	ApiDepotPositionsPath   = "/brokerage/v3/depots/%s/positions"
+	ApiDepotOrdersPath      = "/brokerage/depots/%s/v3/orders"
+	ApiOrdersPath           = "/brokerage/v3/orders"
+	ApiOrdersValidationPath = "/brokerage/v3/orders/validation"
+	ApiOrderPath            = "/brokerage/v3/orders/%s"
+	ApiOrderValidationPath  = "/brokerage/v3/orders/%s/validation"
+)
+
+type Brokerage interface {
+	Depots(context.Context) ([]model.Depot, error)
+	Positions(context.Context, string) ([]model.Position, error)
+	Orders(context.Context, string, OrderFilter) ([]model.Order, error)
+	Order(context.Context, string) (*model.Order, error)
+	PlaceOrder(context.Context, model.OrderRequest, tan.Approver) (*model.Order, error)
+	QuoteTicket(context.Context, string, tan.Approver) (*model.QuoteTicket, error)
+	Quote(context.Context, *model.QuoteTicket, model.QuoteRequest) (*model.Quote, error)
+	PlaceQuoteOrder(context.Context, *model.QuoteTicket, *model.Quote) (*model.Order, error)
+	ChangeOrder(context.Context, model.OrderChange, tan.Approver) (*model.Order, error)
+	CancelOrder(context.Context, string, tan.Approver) (*model.Order, error)
+	Dimensions(context.Context, string) (*model.InstrumentDimension, error)
+	InvalidateDimensions(string)
+	ValidateOrder(context.Context, *model.OrderRequest) error
+}
+
+// pageSize is the number of orders requested per page.
+const pageSize = 100
+
+type OrderFilter struct {
+	Status  model.OrderStatus
+	Side    model.OrderSide
+	Type    model.OrderType
+	VenueId string
+}
+
+type brokerage struct {
+	session.Session
+	cfg        *config.Config
+	dimensions *dimensionCache
+}
+
+func NewBrokerage(cfg *config.Config, s session.Session) Brokerage {
+	return &brokerage{s, cfg, newDimensionCache(cfg.Dimensions.TTL.Duration)}
+}
+
+func (b *brokerage) Depots(ctx context.Context) ([]model.Depot, error) {
+	var data model.DepotsResponse
+	if err := b.do(ctx, http.MethodGet, endpointOf(ApiDepotsPath), nil, nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Values, nil
+}
+
+func (b *brokerage) Positions(ctx context.Context, depotId string) ([]model.Position, error) {
+	var data model.PositionsResponse
+	if err := b.do(ctx, http.MethodGet, endpointOf(ApiDepotPositionsPath, depotId), url.Values{"with-attr": {"instrument"}}, nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Values, nil
+}
+
+// Orders lists the orders of the depot matching the filter, following the
+// paging.
+func (b *brokerage) Orders(ctx context.Context, depotId string, filter OrderFilter) ([]model.Order, error) {
+	var orders []model.Order
+
+	for {
+		q := filter.query()
+		q.Set("paging-first", strconv.Itoa(len(orders)))
+		q.Set("paging-count", strconv.Itoa(pageSize))
+
+		var data model.OrdersResponse
+		if err := b.do(ctx, http.MethodGet, endpointOf(ApiDepotOrdersPath, depotId), q, nil, http.StatusOK, &data); err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, data.Values...)
+		if len(data.Values) == 0 || len(orders) >= data.Paging.Matches {
+			return orders, nil
+		}
+	}
+}
+
+func (b *brokerage) Order(ctx context.Context, orderId string) (*model.Order, error) {
+	var data model.Order
+	if err := b.do(ctx, http.MethodGet, endpointOf(ApiOrderPath, orderId), nil, nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func (b *brokerage) PlaceOrder(ctx context.Context, order model.OrderRequest, approver tan.Approver) (*model.Order, error) {
+	slog.Info("place order", "type", order.OrderType, "depot", order.DepotId)
+
+	if err := b.ValidateOrder(ctx, &order); err != nil {
+		return nil, err
+	}
+
+	authenticationInfo, err := b.validate(ctx, endpointOf(ApiOrdersValidationPath), order, approver)
+	if err != nil {
+		return nil, err
+	}
+
+	var data model.Order
+	if err := b.do(ctx, http.MethodPost, endpointOf(ApiOrdersPath), nil, order, http.StatusCreated, &data, authenticationInfo); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func (b *brokerage) ChangeOrder(ctx context.Context, change model.OrderChange, approver tan.Approver) (*model.Order, error) {
+	slog.Info("change order", "order", change.OrderId)
+
+	order, err := b.Order(ctx, change.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(change.ValidityType) > 0 {
+		r := order.Request()
+		r.ValidityType = change.ValidityType
+		if err := b.ValidateOrder(ctx, &r); err != nil {
+			return nil, err
+		}
+	}
+
+	authenticationInfo, err := b.validate(ctx, endpointOf(ApiOrderValidationPath, change.OrderId), change, approver)
+	if err != nil {
+		return nil, err
+	}
+
+	var data model.Order
+	if err := b.do(ctx, http.MethodPatch, endpointOf(ApiOrderPath, change.OrderId), nil, change, http.StatusOK, &data, authenticationInfo); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func (b *brokerage) CancelOrder(ctx context.Context, orderId string, approver tan.Approver) (*model.Order, error) {
+	slog.Info("cancel order", "order", orderId)
+
+	// a change without any changed attribute validates the cancellation
+	authenticationInfo, err := b.validate(ctx, endpointOf(ApiOrderValidationPath, orderId), model.OrderChange{OrderId: orderId}, approver)
+	if err != nil {
+		return nil, err
+	}
+
+	var data model.Order
+	if err := b.do(ctx, http.MethodDelete, endpointOf(ApiOrderPath, orderId), nil, nil, http.StatusOK, &data, authenticationInfo); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// validate posts the body to the given validation endpoint and lets the
+// approver answer the returned TAN challenge.
+func (b *brokerage) validate(ctx context.Context, e endpoint, body any, approver tan.Approver) (string, error) {
+	resp, err := b.request(ctx, http.MethodPost, e, nil, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", badStatus(resp)
+	}
+
+	challenge, err := tan.FromResponse(resp)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := approver.Approve(ctx, challenge)
+	if err != nil {
+		return "", err
+	}
+
+	return challenge.Header(code), nil
+}
+
+func (b *brokerage) do(ctx context.Context, method string, e endpoint, query url.Values, body any, status int, out any, authenticationInfo ...string) error {
+	resp, err := b.request(ctx, method, e, query, body, authenticationInfo...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != status {
+		return badStatus(resp)
+	}
+
+	return decode(resp, out)
+}
+
+func decode(resp *http.Response, out any) error {
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func badStatus(resp *http.Response) error {
+	return client.BadStatus(resp)
+}
+
+func (b *brokerage) request(ctx context.Context, method string, e endpoint, query url.Values, body any, authenticationInfo ...string) (*http.Response, error) {
+	u := b.cfg.ApiAddress.JoinPath(e.path)
+	u.RawQuery = query.Encode()
+
+	var r io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("x-http-request-info", b.NewRequestInfo())
+	for _, ai := range authenticationInfo {
+		req.Header.Add(tan.HeaderAuthenticationInfo, ai)
+	}
+
+	return client.FromContext(ctx).Do(req, client.WithEndpoint(e.template))
+}
+
+// endpoint keeps the path template next to the formatted path, so requests
+// can be reported without their ids.
+type endpoint struct {
+	template string
+	path     string
+}
+
+func endpointOf(tpl string, args ...any) endpoint {
+	if len(args) == 0 {
+		return endpoint{tpl, tpl}
+	}
+
+	return endpoint{tpl, fmt.Sprintf(tpl, args...)}
+}
+
+func (f OrderFilter) query() url.Values {
+	q := url.Values{}
+	if f.Status != "" {
+		q.Set("order.orderStatus", string(f.Status))
+	}
+	if f.Side != "" {
+		q.Set("order.side", string(f.Side))
+	}
+	if f.Type != "" {
+		q.Set("order.orderType", string(f.Type))
+	}
+	if f.VenueId != "" {
+		q.Set("order.venueId", f.VenueId)
+	}
+
+	return q
+}
@@ -0,0 +1,195 @@
+package brokerage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+const ApiOrderDimensionsPath = "/brokerage/v3/orders/dimensions"
+
+var (
+	ErrNoDimensions    = errors.New("no order dimensions for instrument")
+	ErrOrderNotAllowed = errors.New("order not allowed")
+)
+
+// dimensionCache keeps the order dimensions per instrument for a limited time.
+type dimensionCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]dimensionEntry
+}
+
+type dimensionEntry struct {
+	fetched   time.Time
+	dimension *model.InstrumentDimension
+}
+
+func newDimensionCache(ttl time.Duration) *dimensionCache {
+	return &dimensionCache{
+		ttl:     ttl,
+		entries: make(map[string]dimensionEntry),
+	}
+}
+
+func (dc *dimensionCache) get(instrumentId string) (*model.InstrumentDimension, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	e, ok := dc.entries[instrumentId]
+	if !ok || time.Since(e.fetched) > dc.ttl {
+		delete(dc.entries, instrumentId)
+		return nil, false
+	}
+
+	return e.dimension, true
+}
+
+func (dc *dimensionCache) put(d *model.InstrumentDimension) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	dc.entries[d.InstrumentId] = dimensionEntry{time.Now(), d}
+}
+
+func (dc *dimensionCache) invalidate(instrumentId string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	delete(dc.entries, instrumentId)
+}
+
+func (b *brokerage) Dimensions(ctx context.Context, instrumentId string) (*model.InstrumentDimension, error) {
+	if d, ok := b.dimensions.get(instrumentId); ok {
+		return d, nil
+	}
+
+	var data model.DimensionsResponse
+	if err := b.do(ctx, http.MethodGet, endpointOf(ApiOrderDimensionsPath), url.Values{"instrumentId": {instrumentId}}, nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+
+	if len(data.Values) == 0 {
+		return nil, ErrNoDimensions
+	}
+
+	d := &data.Values[0]
+	if len(d.InstrumentId) == 0 {
+		d.InstrumentId = instrumentId
+	}
+	b.dimensions.put(d)
+
+	return d, nil
+}
+
+func (b *brokerage) InvalidateDimensions(instrumentId string) {
+	b.dimensions.invalidate(instrumentId)
+}
+
+// ValidateOrder checks the order against the cached dimensions of its
+// instrument without contacting the validation endpoint. Legs of combination
+// orders are validated together.
+func (b *brokerage) ValidateOrder(ctx context.Context, o *model.OrderRequest) error {
+	if !o.OrderType.Combination() {
+		d, err := b.Dimensions(ctx, o.InstrumentId)
+		if err != nil {
+			return err
+		}
+
+		return validateOrder(d, o)
+	}
+
+	if err := validateCombination(o); err != nil {
+		return err
+	}
+
+	for i := range o.SubOrders {
+		if err := b.ValidateOrder(ctx, &o.SubOrders[i]); err != nil {
+			return fmt.Errorf("leg %d - %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func validateCombination(o *model.OrderRequest) error {
+	if len(o.SubOrders) != 2 {
+		return fmt.Errorf("%w - %s requires exactly two legs", ErrOrderNotAllowed, o.OrderType)
+	}
+
+	first, second := o.SubOrders[0], o.SubOrders[1]
+	for _, leg := range o.SubOrders {
+		if leg.OrderType.Combination() {
+			return fmt.Errorf("%w - nested combination orders", ErrOrderNotAllowed)
+		}
+		if len(leg.DepotId) > 0 && leg.DepotId != o.DepotId {
+			return fmt.Errorf("%w - legs must use depot %s", ErrOrderNotAllowed, o.DepotId)
+		}
+	}
+
+	if first.InstrumentId != second.InstrumentId {
+		return fmt.Errorf("%w - legs of %s must share the instrument", ErrOrderNotAllowed, o.OrderType)
+	}
+
+	if o.OrderType == model.OrderTypeOneCancelsOther {
+		if first.Side != second.Side {
+			return fmt.Errorf("%w - legs of %s must share the side", ErrOrderNotAllowed, o.OrderType)
+		}
+		if first.Quantity == nil || second.Quantity == nil || first.Quantity.Value != second.Quantity.Value {
+			return fmt.Errorf("%w - legs of %s must share the quantity", ErrOrderNotAllowed, o.OrderType)
+		}
+	}
+
+	return nil
+}
+
+func validateOrder(d *model.InstrumentDimension, o *model.OrderRequest) error {
+	v, ok := d.Venue(o.VenueId)
+	if !ok {
+		return fmt.Errorf("%w - venue %s not available for instrument %s", ErrOrderNotAllowed, o.VenueId, d.InstrumentId)
+	}
+
+	if len(o.Side) > 0 && len(v.Sides) > 0 && !slices.Contains(v.Sides, o.Side) {
+		return fmt.Errorf("%w - side %s not allowed on venue %s", ErrOrderNotAllowed, o.Side, v.Name)
+	}
+
+	ot, ok := v.OrderTypes[o.OrderType]
+	if !ok {
+		return fmt.Errorf("%w - order type %s not allowed on venue %s", ErrOrderNotAllowed, o.OrderType, v.Name)
+	}
+
+	validityTypes := ot.ValidityTypes
+	if len(validityTypes) == 0 {
+		validityTypes = v.ValidityTypes
+	}
+	if len(o.ValidityType) > 0 && len(validityTypes) > 0 && !slices.Contains(validityTypes, o.ValidityType) {
+		return fmt.Errorf("%w - validity type %s not allowed for %s on venue %s", ErrOrderNotAllowed, o.ValidityType, o.OrderType, v.Name)
+	}
+
+	if len(o.LimitExtension) > 0 && !slices.Contains(ot.LimitExtensions, o.LimitExtension) {
+		return fmt.Errorf("%w - limit extension %s not allowed for %s on venue %s", ErrOrderNotAllowed, o.LimitExtension, o.OrderType, v.Name)
+	}
+
+	if o.Quantity != nil && o.Quantity.Value > 0 {
+		if v.MinimumQuantity != nil && o.Quantity.Value < v.MinimumQuantity.Value {
+			return fmt.Errorf("%w - quantity %v below minimum %v on venue %s", ErrOrderNotAllowed, o.Quantity.Value, v.MinimumQuantity.Value, v.Name)
+		}
+
+		if v.QuantityIncrement != nil && v.QuantityIncrement.Value > 0 {
+			steps := o.Quantity.Value / v.QuantityIncrement.Value
+			if math.Abs(steps-math.Round(steps)) > 1e-9 {
+				return fmt.Errorf("%w - quantity %v not a multiple of %v on venue %s", ErrOrderNotAllowed, o.Quantity.Value, v.QuantityIncrement.Value, v.Name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package brokerage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/tan"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+const (
+	ApiQuoteTicketPath       = "/brokerage/v3/quoteticket"
+	ApiQuoteTicketUpdatePath = "/brokerage/v3/quoteticket/%s"
+	ApiQuotesPath            = "/brokerage/v3/quotes"
+)
+
+// QuoteValidity is how long a quote is considered binding after its
+// creation when the response carries no expiry.
+const QuoteValidity = 5 * time.Second
+
+var (
+	ErrQuoteExpired  = errors.New("quote expired before confirmation")
+	ErrTicketExpired = errors.New("quote ticket expired")
+)
+
+// QuoteTicket opens a ticket for direct trading and activates it with a TAN.
+func (b *brokerage) QuoteTicket(ctx context.Context, depotId string, approver tan.Approver) (*model.QuoteTicket, error) {
+	slog.Info("open quote ticket", "depot", depotId)
+
+	resp, err := b.request(ctx, http.MethodPost, endpointOf(ApiQuoteTicketPath), nil, model.QuoteTicketRequest{DepotId: depotId})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, badStatus(resp)
+	}
+
+	var ticket model.QuoteTicket
+	if err := decode(resp, &ticket); err != nil {
+		return nil, err
+	}
+
+	if len(ticket.ExpiryTimestamp) > 0 {
+		if ticket.Expiry, err = model.ParseTimestamp(ticket.ExpiryTimestamp); err != nil {
+			slog.Warn("invalid quote ticket expiry", "expiry", ticket.ExpiryTimestamp, "error", err)
+		}
+	}
+
+	challenge, err := tan.FromResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := approver.Approve(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	ticket.AuthenticationInfo = challenge.Header(code)
+
+	if err := b.do(ctx, http.MethodPatch, endpointOf(ApiQuoteTicketUpdatePath, ticket.QuoteTicketId), nil, ticket, http.StatusOK, nil, ticket.AuthenticationInfo); err != nil {
+		return nil, err
+	}
+
+	return &ticket, nil
+}
+
+// Quote requests a binding quote on an activated ticket.
+func (b *brokerage) Quote(ctx context.Context, ticket *model.QuoteTicket, req model.QuoteRequest) (*model.Quote, error) {
+	req.QuoteTicketId = ticket.QuoteTicketId
+	req.DepotId = ticket.DepotId
+
+	var quote model.Quote
+	received := time.Now()
+	if err := b.do(ctx, http.MethodPost, endpointOf(ApiQuotesPath), nil, req, http.StatusCreated, &quote); err != nil {
+		return nil, err
+	}
+	quote.Expiry = quoteExpiry(&quote, received)
+
+	return &quote, nil
+}
+
+// quoteExpiry prefers the expiry of the response over its creation time plus
+// QuoteValidity. Without either the quote is binding for QuoteValidity after
+// it was requested.
+func quoteExpiry(quote *model.Quote, requested time.Time) time.Time {
+	if len(quote.ExpiryTimestamp) > 0 {
+		if t, err := model.ParseTimestamp(quote.ExpiryTimestamp); err == nil {
+			return t
+		}
+		slog.Warn("invalid quote expiry", "expiry", quote.ExpiryTimestamp)
+	}
+
+	if len(quote.CreationTimestamp) > 0 {
+		if t, err := model.ParseTimestamp(quote.CreationTimestamp); err == nil {
+			return t.Add(QuoteValidity)
+		}
+		slog.Warn("invalid quote creation time", "creation", quote.CreationTimestamp)
+	}
+
+	return requested.Add(QuoteValidity)
+}
+
+// PlaceQuoteOrder places the order referencing the quote, authorized by the
+// TAN given for the ticket.
+func (b *brokerage) PlaceQuoteOrder(ctx context.Context, ticket *model.QuoteTicket, quote *model.Quote) (*model.Order, error) {
+	if ticket.Expired() {
+		return nil, ErrTicketExpired
+	}
+
+	if quote.Expired() {
+		return nil, ErrQuoteExpired
+	}
+
+	slog.Info("place quote order", "quote", quote.QuoteId)
+
+	order := model.OrderRequest{
+		DepotId:      ticket.DepotId,
+		OrderType:    model.OrderTypeQuote,
+		Side:         quote.Side,
+		InstrumentId: quote.InstrumentId,
+		QuoteId:      quote.QuoteId,
+		VenueId:      quote.VenueId,
+		Quantity:     &quote.Quantity,
+		Limit:        &quote.Limit,
+	}
+
+	var data model.Order
+	if err := b.do(ctx, http.MethodPost, endpointOf(ApiOrdersPath), nil, order, http.StatusCreated, &data, ticket.AuthenticationInfo); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
@@ -0,0 +1,129 @@
+package brokerage
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+// OrderTracker polls the orders of all depots and publishes an event for every
+// state transition or additional fill compared to the last known state. All
+// listed orders are compared, not only open ones, so orders executed,
+// cancelled or expired between two polls are seen as well.
+type OrderTracker struct {
+	brokerage Brokerage
+	bus       event.Bus
+
+	mu     sync.Mutex
+	seeded bool
+	known  map[string]orderState
+}
+
+type orderState struct {
+	depotId  string
+	parentId string
+	status   model.OrderStatus
+	executed float64
+}
+
+func NewOrderTracker(b Brokerage, bus event.Bus) *OrderTracker {
+	return &OrderTracker{
+		brokerage: b,
+		bus:       bus,
+		known:     make(map[string]orderState),
+	}
+}
+
+func (t *OrderTracker) Poll(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	depots, err := t.brokerage.Depots(ctx)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range depots {
+		orders, err := t.brokerage.Orders(ctx, d.DepotId, OrderFilter{})
+		if err != nil {
+			return err
+		}
+
+		for _, o := range orders {
+			seen[o.OrderId] = true
+			t.update(&o, "")
+
+			// legs of combination orders are tracked linked to their parent
+			for _, so := range o.SubOrders {
+				seen[so.OrderId] = true
+				t.update(&so, o.OrderId)
+			}
+		}
+	}
+
+	// orders no longer listed but not known as final, fetch their state once
+	for id, s := range t.known {
+		if seen[id] || s.status.Final() {
+			continue
+		}
+
+		o, err := t.brokerage.Order(ctx, id)
+		if err != nil {
+			return err
+		}
+		t.update(o, s.parentId)
+	}
+
+	// final orders are kept while listed, so they are not reported as new
+	for id, s := range t.known {
+		if s.status.Final() && !seen[id] {
+			delete(t.known, id)
+		}
+	}
+
+	t.seeded = true
+
+	return nil
+}
+
+func (t *OrderTracker) update(o *model.Order, parentId string) {
+	status := o.OrderStatus
+	if o.PartiallyExecuted() {
+		status = model.OrderStatusPartiallyExecuted
+	}
+
+	current := orderState{o.DepotId, parentId, status, o.Executed()}
+	last, ok := t.known[o.OrderId]
+	t.known[o.OrderId] = current
+
+	// the first poll only learns the current state
+	if !t.seeded || (ok && last == current) {
+		return
+	}
+
+	slog.Info("order changed", "order", o.OrderId, "from", last.status, "to", status, "executed", current.executed)
+
+	change := event.OrderStateChange{
+		DepotId:          o.DepotId,
+		OrderId:          o.OrderId,
+		ParentOrderId:    parentId,
+		InstrumentId:     o.InstrumentId,
+		Side:             o.Side,
+		From:             last.status,
+		To:               status,
+		Quantity:         o.Quantity.Value,
+		ExecutedQuantity: current.executed,
+	}
+	if p, ok := o.AveragePrice(); ok {
+		change.AveragePrice = &p
+	}
+	if n := len(o.Executions); n > 0 {
+		change.LastExecution = &o.Executions[n-1]
+	}
+
+	t.bus.Publish(event.OrderStateChanged, change)
+}
@@ -0,0 +1,165 @@
+package brokerage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+// fakeBrokerage lists the orders of the current poll, orders not listed are
+// answered from single.
+type fakeBrokerage struct {
+	Brokerage
+	orders []model.Order
+	single map[string]model.Order
+}
+
+func (b *fakeBrokerage) Depots(context.Context) ([]model.Depot, error) {
+	return []model.Depot{{DepotId: "d1"}}, nil
+}
+
+func (b *fakeBrokerage) Orders(context.Context, string, OrderFilter) ([]model.Order, error) {
+	return b.orders, nil
+}
+
+func (b *fakeBrokerage) Order(_ context.Context, id string) (*model.Order, error) {
+	o := b.single[id]
+	return &o, nil
+}
+
+type recordingBus struct {
+	events []event.Event
+}
+
+func (b *recordingBus) Publish(t event.Type, data any) {
+	b.events = append(b.events, event.Event{Type: t, Data: data})
+}
+
+func (b *recordingBus) Subscribe(context.Context, func(event.Event), ...event.Type) {}
+
+func (b *recordingBus) SubscribeBounded(context.Context, func(event.Event), ...event.Type) {}
+
+func order(id string, status model.OrderStatus, executed float64) model.Order {
+	o := model.Order{DepotId: "d1", OrderId: id, OrderStatus: status, Quantity: model.Amount{Value: 10}}
+	if executed > 0 {
+		o.ExecutedQuantity = &model.Amount{Value: executed}
+	}
+	return o
+}
+
+type transition struct {
+	order    string
+	from, to model.OrderStatus
+	executed float64
+}
+
+func TestOrderTracker(t *testing.T) {
+	tests := []struct {
+		name   string
+		polls  [][]model.Order
+		single map[string]model.Order
+		want   []transition
+	}{
+		{
+			name: "first poll only learns",
+			polls: [][]model.Order{
+				{order("o1", model.OrderStatusOpen, 0), order("o2", model.OrderStatusExecuted, 10)},
+			},
+		},
+		{
+			name: "unchanged",
+			polls: [][]model.Order{
+				{order("o1", model.OrderStatusOpen, 0)},
+				{order("o1", model.OrderStatusOpen, 0)},
+			},
+		},
+		{
+			name: "partial fill then execution",
+			polls: [][]model.Order{
+				{order("o1", model.OrderStatusOpen, 0)},
+				{order("o1", model.OrderStatusOpen, 4)},
+				{order("o1", model.OrderStatusExecuted, 10)},
+			},
+			want: []transition{
+				{"o1", model.OrderStatusOpen, model.OrderStatusPartiallyExecuted, 4},
+				{"o1", model.OrderStatusPartiallyExecuted, model.OrderStatusExecuted, 10},
+			},
+		},
+		{
+			name: "new order",
+			polls: [][]model.Order{
+				{},
+				{order("o1", model.OrderStatusOpen, 0)},
+			},
+			want: []transition{
+				{"o1", "", model.OrderStatusOpen, 0},
+			},
+		},
+		{
+			name: "unlisted order is fetched",
+			polls: [][]model.Order{
+				{order("o1", model.OrderStatusOpen, 0)},
+				{},
+			},
+			single: map[string]model.Order{"o1": order("o1", model.OrderStatusCancelledUser, 0)},
+			want: []transition{
+				{"o1", model.OrderStatusOpen, model.OrderStatusCancelledUser, 0},
+			},
+		},
+		{
+			name: "final order still listed is not reported again",
+			polls: [][]model.Order{
+				{order("o1", model.OrderStatusOpen, 0)},
+				{order("o1", model.OrderStatusExecuted, 10)},
+				{order("o1", model.OrderStatusExecuted, 10)},
+				{},
+			},
+			want: []transition{
+				{"o1", model.OrderStatusOpen, model.OrderStatusExecuted, 10},
+			},
+		},
+		{
+			name: "legs of combination orders",
+			polls: [][]model.Order{
+				{{DepotId: "d1", OrderId: "p", OrderStatus: model.OrderStatusOpen, SubOrders: []model.Order{
+					order("l1", model.OrderStatusOpen, 0), order("l2", model.OrderStatusOpen, 0),
+				}}},
+				{{DepotId: "d1", OrderId: "p", OrderStatus: model.OrderStatusOpen, SubOrders: []model.Order{
+					order("l1", model.OrderStatusExecuted, 10), order("l2", model.OrderStatusCancelledSystem, 0),
+				}}},
+			},
+			want: []transition{
+				{"l1", model.OrderStatusOpen, model.OrderStatusExecuted, 10},
+				{"l2", model.OrderStatusOpen, model.OrderStatusCancelledSystem, 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &fakeBrokerage{single: tt.single}
+			bus := &recordingBus{}
+			tracker := NewOrderTracker(b, bus)
+
+			for _, orders := range tt.polls {
+				b.orders = orders
+				if err := tracker.Poll(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got []transition
+			for _, e := range bus.events {
+				c := e.Data.(event.OrderStateChange)
+				got = append(got, transition{c.OrderId, c.From, c.To, c.ExecutedQuantity})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
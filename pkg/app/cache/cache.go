@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+// Cache keeps the latest fetched data in memory, so the local API can answer
+// without talking to comdirect.
+type Cache struct {
+	bus         event.Bus
+	mu          sync.RWMutex
+	snapshot    *event.Snapshot
+	documents   []model.Document
+	documentsAt time.Time
+}
+
+func NewCache(bus event.Bus) *Cache {
+	return &Cache{bus: bus}
+}
+
+// Subscribe keeps the cache up to date with every fetched snapshot.
+func (c *Cache) Subscribe(ctx context.Context) {
+	c.bus.Subscribe(ctx, func(e event.Event) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.snapshot = e.Data.(*event.Snapshot)
+	}, event.SnapshotFetched)
+}
+
+// Snapshot returns the latest snapshot or nil if nothing was fetched yet.
+func (c *Cache) Snapshot() *event.Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.snapshot
+}
+
+func (c *Cache) SetDocuments(t time.Time, documents []model.Document) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.documents = documents
+	c.documentsAt = t
+}
+
+// Documents returns the latest postbox listing and when it was fetched.
+func (c *Cache) Documents() ([]model.Document, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.documents, c.documentsAt
+}
@@ -0,0 +1,224 @@
+package change
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/config"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+const stateName = "changes"
+
+// Detector diffs every fetched result against the last known state and
+// publishes typed events for the differences. The state is kept in the store,
+// so a restart neither repeats nor loses events.
+type Detector struct {
+	cfg   *config.Config
+	bus   event.Bus
+	store store.Store
+
+	mu    sync.Mutex
+	state state
+}
+
+// state holds the last known values, a nil map means nothing is known yet
+// and the next result is taken without publishing events.
+type state struct {
+	Balances     map[string]model.Amount  `json:"balances"`
+	Positions    map[string]positionState `json:"positions"`
+	Transactions map[string][]string      `json:"transactions"`
+	Documents    map[string]bool          `json:"documents"`
+}
+
+type positionState struct {
+	Quantity float64      `json:"quantity"`
+	Price    model.Amount `json:"price"`
+}
+
+func NewDetector(cfg *config.Config, bus event.Bus, st store.Store) *Detector {
+	d := &Detector{cfg: cfg, bus: bus, store: st}
+
+	if err := st.Load(stateName, &d.state); err != nil && !errors.Is(err, store.ErrNoState) {
+		slog.Error("failed to load change state", "error", err)
+	}
+
+	return d
+}
+
+// Snapshot publishes the changes of the snapshot followed by the snapshot
+// itself.
+func (d *Detector) Snapshot(s *event.Snapshot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.balances(s); err != nil {
+		return err
+	}
+	d.positions(s)
+	d.transactions(s)
+
+	d.bus.Publish(event.SnapshotFetched, s)
+
+	return d.save()
+}
+
+func (d *Detector) Documents(documents []model.Document) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seed := d.state.Documents == nil
+	if seed {
+		d.state.Documents = make(map[string]bool)
+	}
+
+	for _, doc := range documents {
+		if d.state.Documents[doc.DocumentId] {
+			continue
+		}
+		d.state.Documents[doc.DocumentId] = true
+
+		if !seed {
+			d.bus.Publish(event.DocumentArrived, event.DocumentArrival{Document: doc})
+		}
+	}
+
+	return d.save()
+}
+
+func (d *Detector) balances(s *event.Snapshot) error {
+	seed := d.state.Balances == nil
+	if seed {
+		d.state.Balances = make(map[string]model.Amount)
+	}
+
+	for _, v := range s.Balances.Values {
+		var current model.Amount
+
+		switch v.ProductType {
+		case model.ProductTypeDepot:
+			// depot values follow the prices, see positions
+			continue
+		case model.ProductTypeCard:
+			b, err := v.CardBalance()
+			if err != nil {
+				return err
+			}
+			current = b.Balance
+		default:
+			b, err := v.AccountBalance()
+			if err != nil {
+				return err
+			}
+			current = model.Amount(b.Balance)
+		}
+
+		previous, ok := d.state.Balances[v.ProductId]
+		d.state.Balances[v.ProductId] = current
+
+		if !seed && ok && previous != current {
+			d.bus.Publish(event.BalanceChanged, event.BalanceChange{
+				ProductId:   v.ProductId,
+				ProductType: v.ProductType,
+				Previous:    previous,
+				Current:     current,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (d *Detector) positions(s *event.Snapshot) {
+	seed := d.state.Positions == nil
+	if seed {
+		d.state.Positions = make(map[string]positionState)
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range s.Positions {
+		key := p.DepotId + "/" + p.InstrumentId
+		seen[key] = true
+
+		previous, ok := d.state.Positions[key]
+		current := positionState{p.Quantity.Value, p.CurrentPrice.Price}
+
+		if !seed && ok && previous.Quantity != current.Quantity {
+			change := positionChange(&p)
+			change.Previous = &model.Amount{Value: previous.Quantity, Unit: p.Quantity.Unit}
+			d.bus.Publish(event.QuantityChanged, change)
+		}
+
+		switch {
+		case seed:
+		case !ok:
+			d.bus.Publish(event.PositionAdded, positionChange(&p))
+		case previous.Price.Value != 0 && math.Abs(current.Price.Value/previous.Price.Value-1)*100 >= d.cfg.Events.PriceThreshold:
+			d.bus.Publish(event.PriceMoved, event.PriceMove{
+				DepotId:      p.DepotId,
+				InstrumentId: p.InstrumentId,
+				Instrument:   p.Instrument,
+				Previous:     previous.Price,
+				Current:      current.Price,
+				Change:       (current.Price.Value/previous.Price.Value - 1) * 100,
+			})
+		default:
+			// prices are compared to the last reported one, so slow drifts
+			// are reported as well
+			current.Price = previous.Price
+		}
+
+		d.state.Positions[key] = current
+	}
+
+	for key := range d.state.Positions {
+		if seen[key] {
+			continue
+		}
+
+		delete(d.state.Positions, key)
+		depotId, instrumentId, _ := strings.Cut(key, "/")
+		d.bus.Publish(event.PositionRemoved, event.PositionChange{DepotId: depotId, InstrumentId: instrumentId})
+	}
+}
+
+func (d *Detector) transactions(s *event.Snapshot) {
+	if d.state.Transactions == nil {
+		d.state.Transactions = make(map[string][]string)
+	}
+
+	for accountId, transactions := range s.Transactions {
+		known, ok := d.state.Transactions[accountId]
+
+		var references []string
+		for _, t := range transactions {
+			references = append(references, t.Reference)
+
+			if ok && !slices.Contains(known, t.Reference) {
+				d.bus.Publish(event.NewTransaction, event.TransactionArrival{AccountId: accountId, Transaction: t})
+			}
+		}
+
+		d.state.Transactions[accountId] = references
+	}
+}
+
+func (d *Detector) save() error {
+	return d.store.Save(stateName, &d.state)
+}
+
+func positionChange(p *model.Position) event.PositionChange {
+	return event.PositionChange{
+		DepotId:      p.DepotId,
+		InstrumentId: p.InstrumentId,
+		Instrument:   p.Instrument,
+		Quantity:     p.Quantity,
+		Value:        p.CurrentValue,
+	}
+}
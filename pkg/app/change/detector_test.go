@@ -0,0 +1,204 @@
+package change
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/config"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+type recordingBus struct {
+	types []event.Type
+}
+
+func (b *recordingBus) Publish(t event.Type, _ any) {
+	if t != event.SnapshotFetched {
+		b.types = append(b.types, t)
+	}
+}
+
+func (b *recordingBus) Subscribe(context.Context, func(event.Event), ...event.Type) {}
+
+func (b *recordingBus) SubscribeBounded(context.Context, func(event.Event), ...event.Type) {}
+
+func position(instrumentId string, quantity, price float64) model.Position {
+	return model.Position{
+		DepotId:      "d1",
+		InstrumentId: instrumentId,
+		Quantity:     model.Amount{Value: quantity, Unit: "XXX"},
+		CurrentPrice: model.Price{Price: model.Amount{Value: price, Unit: "EUR"}},
+	}
+}
+
+func account(productId string, balance float64) model.ReportBalance {
+	return model.ReportBalance{
+		ProductId:   productId,
+		ProductType: model.ProductTypeAccount,
+		Balance:     []byte(fmt.Sprintf(`{"balance":{"value":"%v","unit":"EUR"}}`, balance)),
+	}
+}
+
+func transactions(references ...string) map[string][]model.Transaction {
+	var t []model.Transaction
+	for _, r := range references {
+		t = append(t, model.Transaction{Reference: r})
+	}
+
+	return map[string][]model.Transaction{"a1": t}
+}
+
+func TestDetectorSnapshot(t *testing.T) {
+	tests := []struct {
+		name      string
+		snapshots []event.Snapshot
+		restart   bool
+		want      []event.Type
+	}{
+		{
+			name: "first snapshot only learns",
+			snapshots: []event.Snapshot{
+				{Positions: []model.Position{position("i1", 10, 100)}, Transactions: transactions("t1")},
+			},
+		},
+		{
+			name: "balance changed",
+			snapshots: []event.Snapshot{
+				{Balances: &model.AllBalancesResponse{Values: []model.ReportBalance{account("a1", 100)}}},
+				{Balances: &model.AllBalancesResponse{Values: []model.ReportBalance{account("a1", 100)}}},
+				{Balances: &model.AllBalancesResponse{Values: []model.ReportBalance{account("a1", 80)}}},
+			},
+			want: []event.Type{event.BalanceChanged},
+		},
+		{
+			name: "position added and removed",
+			snapshots: []event.Snapshot{
+				{Positions: []model.Position{position("i1", 10, 100)}},
+				{Positions: []model.Position{position("i1", 10, 100), position("i2", 5, 20)}},
+				{Positions: []model.Position{position("i2", 5, 20)}},
+			},
+			want: []event.Type{event.PositionAdded, event.PositionRemoved},
+		},
+		{
+			name: "quantity changed",
+			snapshots: []event.Snapshot{
+				{Positions: []model.Position{position("i1", 10, 100)}},
+				{Positions: []model.Position{position("i1", 15, 100)}},
+			},
+			want: []event.Type{event.QuantityChanged},
+		},
+		{
+			name: "price drifts past the threshold",
+			snapshots: []event.Snapshot{
+				{Positions: []model.Position{position("i1", 10, 100)}},
+				{Positions: []model.Position{position("i1", 10, 100.6)}},
+				{Positions: []model.Position{position("i1", 10, 101.2)}},
+				{Positions: []model.Position{position("i1", 10, 101.5)}},
+			},
+			want: []event.Type{event.PriceMoved},
+		},
+		{
+			name: "new transaction",
+			snapshots: []event.Snapshot{
+				{Transactions: transactions("t1")},
+				{Transactions: transactions("t2", "t1")},
+				{Transactions: transactions("t2", "t1")},
+			},
+			want: []event.Type{event.NewTransaction},
+		},
+		{
+			name: "state survives a restart",
+			snapshots: []event.Snapshot{
+				{Positions: []model.Position{position("i1", 10, 100)}},
+				{Positions: []model.Position{position("i1", 10, 100), position("i2", 5, 20)}},
+			},
+			restart: true,
+			want:    []event.Type{event.PositionAdded},
+		},
+	}
+
+	cfg := config.Config{Events: config.Events{PriceThreshold: 1}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := store.Open(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer st.Close()
+
+			bus := &recordingBus{}
+			d := NewDetector(&cfg, bus, st)
+
+			for i := range tt.snapshots {
+				if tt.restart {
+					d = NewDetector(&cfg, bus, st)
+				}
+				if tt.snapshots[i].Balances == nil {
+					tt.snapshots[i].Balances = &model.AllBalancesResponse{}
+				}
+				if err := d.Snapshot(&tt.snapshots[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !reflect.DeepEqual(bus.types, tt.want) {
+				t.Errorf("got %v, want %v", bus.types, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectorDocuments(t *testing.T) {
+	tests := []struct {
+		name  string
+		polls [][]string
+		want  int
+	}{
+		{
+			name:  "first poll only learns",
+			polls: [][]string{{"doc1", "doc2"}},
+		},
+		{
+			name:  "new documents",
+			polls: [][]string{{"doc1"}, {"doc2", "doc1"}, {"doc3", "doc2", "doc1"}},
+			want:  2,
+		},
+		{
+			name:  "empty first poll",
+			polls: [][]string{{}, {"doc1"}},
+			want:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := store.Open(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer st.Close()
+
+			bus := &recordingBus{}
+			d := NewDetector(&config.Config{}, bus, st)
+
+			for _, ids := range tt.polls {
+				var documents []model.Document
+				for _, id := range ids {
+					documents = append(documents, model.Document{DocumentId: id})
+				}
+				if err := d.Documents(documents); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if len(bus.types) != tt.want {
+				t.Errorf("got %d events, want %d", len(bus.types), tt.want)
+			}
+		})
+	}
+}
@@ -4,23 +4,33 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	api_error "github.com/kaedwen/trade/pkg/app/error"
-	"github.com/kaedwen/trade/pkg/app/utils"
+	"github.com/kaedwen/trade/pkg/app/logging"
+	"github.com/kaedwen/trade/pkg/app/metrics"
 	"github.com/kaedwen/trade/pkg/config"
 	"golang.org/x/oauth2"
 )
 
+// ErrUnauthorized means the token or the session is no longer valid.
+var ErrUnauthorized = errors.New("unauthorized")
+
 type Client interface {
 	Connect(context.Context) (context.Context, error)
+	Restore(context.Context) (context.Context, error)
 	OAuthSecondFlow(ctx context.Context) (context.Context, error)
 	Do(*http.Request, ...ClientOption) (*http.Response, error)
+	Expiry() time.Time
 }
 
 type ClientOption func(*clientOptions)
@@ -30,6 +40,7 @@ type clientOptions struct {
 	retryStatusCodes []int
 	retryDelay       time.Duration
 	retryMax         int
+	endpoint         string
 }
 
 type oauthSecondaryFlowResponse struct {
@@ -61,11 +72,22 @@ func WithRetryMax(max int) ClientOption {
 	}
 }
 
+// WithEndpoint names the request by its path template, e.g.
+// "/brokerage/v3/orders/%s", so metrics do not carry raw ids.
+func WithEndpoint(tpl string) ClientOption {
+	return func(co *clientOptions) {
+		co.endpoint = tpl
+	}
+}
+
 type client struct {
+	// mu guards Client and tks, which are replaced on every login
+	mu sync.RWMutex
 	*http.Client
 	cfg *config.Config
 	oac *oauth2.Config
 	tks oauth2.TokenSource
+	exp atomic.Int64
 }
 
 func NewClient(cfg *config.Config) Client {
@@ -81,22 +103,25 @@ func NewClient(cfg *config.Config) Client {
 }
 
 func (c *client) Connect(ctx context.Context) (context.Context, error) {
-	log.Println("oauth flow")
+	slog.Info("oauth flow")
 
 	tk, err := c.oac.PasswordCredentialsToken(ctx, c.cfg.AccountId, c.cfg.Pin)
 	if err != nil {
 		return nil, err
 	}
 
-	c.tks = c.oac.TokenSource(ctx, tk)
-	c.Client = &http.Client{Transport: &jsonTransport{oauth2.NewClient(ctx, c.tks).Transport}}
+	c.set(ctx, tk, false)
 	return contextWithClient(ctx, c), nil
 }
 
 func (c *client) OAuthSecondFlow(ctx context.Context) (context.Context, error) {
-	log.Println("oauth cd_secondary flow")
+	slog.Info("oauth cd_secondary flow")
+
+	c.mu.RLock()
+	tks := c.tks
+	c.mu.RUnlock()
 
-	tk, err := c.tks.Token()
+	tk, err := tks.Token()
 	if err != nil {
 		return nil, err
 	}
@@ -122,8 +147,7 @@ func (c *client) OAuthSecondFlow(ctx context.Context) (context.Context, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Println(utils.ReadAllString(resp.Body))
-		return nil, api_error.ErrApiBadStatus
+		return nil, BadStatus(resp)
 	}
 
 	var oauthSecondaryFlowResponse oauthSecondaryFlowResponse
@@ -138,34 +162,84 @@ func (c *client) OAuthSecondFlow(ctx context.Context) (context.Context, error) {
 		Expiry:       time.Now().Add(time.Duration(oauthSecondaryFlowResponse.ExpiresIn * int64(time.Second))),
 	}
 
-	log.Printf("token expires at %v\n", stk.Expiry)
+	slog.Info("token received", "expiry", stk.Expiry)
 
-	c.tks = c.oac.TokenSource(ctx, stk)
-	c.Client = &http.Client{Transport: &jsonTransport{oauth2.NewClient(ctx, c.tks).Transport}}
+	c.set(ctx, stk, true)
 	return contextWithClient(ctx, c), nil
 }
 
+// set replaces the token, persist keeps it and its refreshes for Restore.
+func (c *client) set(ctx context.Context, tk *oauth2.Token, persist bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ts := &expiryTokenSource{TokenSource: c.oac.TokenSource(ctx, tk), exp: &c.exp}
+	if persist {
+		ts.save = c.saveToken
+	}
+	c.tks = ts
+
+	var rt http.RoundTripper = &jsonTransport{oauth2.NewClient(ctx, c.tks).Transport}
+	if c.cfg.Log.Trace || c.cfg.Log.Dump {
+		rt = &traceTransport{RoundTripper: rt, dump: c.cfg.Log.Dump}
+	}
+	c.Client = &http.Client{Transport: rt}
+}
+
 func (c *client) Do(req *http.Request, opt ...ClientOption) (resp *http.Response, err error) {
 	opts := clientOptions{
 		retryDelay: time.Second,
 		retryMax:   10,
+		endpoint:   "unknown",
 	}
 
 	for _, o := range opt {
 		o(&opts)
 	}
 
-	for {
-		resp, err = c.Client.Do(req.Clone(req.Context()))
+	ctx := context.WithValue(req.Context(), endpointContextKey, opts.endpoint)
+	log := slog.With("endpoint", opts.endpoint, "method", req.Method, "request_id", RequestId(req))
+
+	for attempt := 1; ; attempt++ {
+		c.mu.RLock()
+		hc := c.Client
+		c.mu.RUnlock()
+
+		start := time.Now()
+		resp, err = hc.Do(req.Clone(context.WithValue(ctx, attemptContextKey, attempt)))
+		metrics.ApiRequestDuration.With(opts.endpoint, req.Method).Observe(time.Since(start).Seconds())
 		if err != nil {
+			log.Warn("api request failed", "error", err)
+			metrics.ApiRequests.With(opts.endpoint, req.Method, "error").Inc()
+
+			// the oauth2 transport failed to refresh the token
+			var re *oauth2.RetrieveError
+			if errors.As(err, &re) {
+				err = fmt.Errorf("%w - %v", ErrUnauthorized, err)
+			}
 			return
 		}
 
+		status := strconv.Itoa(resp.StatusCode)
+		metrics.ApiRequests.With(opts.endpoint, req.Method, status).Inc()
+		log.Debug("api request", "status", resp.StatusCode, "duration", time.Since(start), "attempt", attempt)
+
+		if resp.StatusCode == http.StatusUnauthorized {
+			resp.Body.Close()
+			return nil, ErrUnauthorized
+		}
+
 		if slices.Contains(opts.retryStatusCodes, resp.StatusCode) {
+			metrics.ApiRetries.With(opts.endpoint, status).Inc()
+			if resp.StatusCode == http.StatusTooManyRequests {
+				metrics.ApiRateLimitWaits.With(opts.endpoint).Inc()
+			}
+
 			time.Sleep(opts.retryDelay)
 
 			opts.retryMax--
 			if opts.retryMax > 0 {
+				resp.Body.Close()
 				continue
 			} else {
 				return resp, errors.New("retry failed")
@@ -176,26 +250,120 @@ func (c *client) Do(req *http.Request, opt ...ClientOption) (resp *http.Response
 	}
 }
 
+// expiryTokenSource publishes the expiry of every token handed out, which
+// covers refreshes done by the oauth2 transport, and saves new tokens.
+type expiryTokenSource struct {
+	oauth2.TokenSource
+	exp  *atomic.Int64
+	save func(*oauth2.Token)
+
+	mu   sync.Mutex
+	last string
+}
+
+func (ets *expiryTokenSource) Token() (*oauth2.Token, error) {
+	tk, err := ets.TokenSource.Token()
+	if err == nil && !tk.Expiry.IsZero() {
+		ets.exp.Store(tk.Expiry.Unix())
+		metrics.TokenExpiry.With().SetTime(tk.Expiry)
+	}
+
+	if err == nil && ets.save != nil {
+		ets.mu.Lock()
+		if tk.AccessToken != ets.last {
+			ets.last = tk.AccessToken
+			ets.save(tk)
+		}
+		ets.mu.Unlock()
+	}
+
+	return tk, err
+}
+
+// Expiry returns the expiry of the last access token handed out.
+func (c *client) Expiry() time.Time {
+	if exp := c.exp.Load(); exp > 0 {
+		return time.Unix(exp, 0)
+	}
+
+	return time.Time{}
+}
+
 type jsonTransport struct {
 	http.RoundTripper
 }
 
 func (jt *jsonTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req2 := req.Clone(req.Context())
-	req2.Header.Add("Content-Type", "application/json")
-	req2.Header.Add("Accept", "application/json")
+	if len(req2.Header.Get("Content-Type")) == 0 {
+		req2.Header.Set("Content-Type", "application/json")
+	}
+	if len(req2.Header.Get("Accept")) == 0 {
+		req2.Header.Set("Accept", "application/json")
+	}
 
 	return jt.RoundTripper.RoundTrip(req2)
 }
 
 type contextKey string
 
-const clientContextKey contextKey = "client-context"
+const (
+	clientContextKey   contextKey = "client-context"
+	endpointContextKey contextKey = "endpoint"
+)
+
+// Endpoint returns the endpoint template a request was sent with by Do.
+func Endpoint(ctx context.Context) string {
+	if e, ok := ctx.Value(endpointContextKey).(string); ok {
+		return e
+	}
+
+	return "unknown"
+}
+
+// RequestId returns the request id of the x-http-request-info header, which
+// identifies the request towards comdirect.
+func RequestId(req *http.Request) string {
+	var ri struct {
+		ClientRequestId struct {
+			RequestId string `json:"requestId"`
+		} `json:"clientRequestId"`
+	}
+
+	if err := json.Unmarshal([]byte(req.Header.Get("x-http-request-info")), &ri); err != nil {
+		return ""
+	}
+
+	return ri.ClientRequestId.RequestId
+}
+
+// BadStatus logs a response with an unexpected status code. Its body may hold
+// personal data and is only logged redacted on debug level.
+func BadStatus(resp *http.Response) error {
+	args := []any{"status", resp.StatusCode}
+	if req := resp.Request; req != nil {
+		args = append(args, "endpoint", Endpoint(req.Context()), "method", req.Method, "request_id", RequestId(req))
+	}
+
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		args = append(args, "body", logging.Body(resp.Body))
+	}
+
+	slog.Warn("unexpected api status", args...)
+
+	return api_error.ErrApiBadStatus
+}
 
 func contextWithClient(ctx context.Context, c Client) context.Context {
 	return context.WithValue(ctx, clientContextKey, c)
 }
 
+// NewContext returns a context carrying c, for clients not created by
+// NewClient.
+func NewContext(ctx context.Context, c Client) context.Context {
+	return contextWithClient(ctx, c)
+}
+
 func FromContext(ctx context.Context) Client {
 	return ctx.Value(clientContextKey).(Client)
 }
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenFile keeps the token of the TAN activated session in the store
+// directory, so a restart continues the session instead of asking for a TAN.
+const tokenFile = "token.json"
+
+var ErrNoToken = errors.New("no stored token")
+
+// Restore continues the session of the stored token with a refresh grant. The
+// stored token is removed when it is rejected.
+func (c *client) Restore(ctx context.Context) (context.Context, error) {
+	data, err := os.ReadFile(c.tokenFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	var stored oauth2.Token
+	if err := json.Unmarshal(data, &stored); err != nil || len(stored.RefreshToken) == 0 {
+		return nil, ErrNoToken
+	}
+
+	slog.Info("oauth refresh flow")
+
+	// without an access token the source refreshes right away
+	tk, err := c.oac.TokenSource(ctx, &oauth2.Token{RefreshToken: stored.RefreshToken}).Token()
+	if err != nil {
+		c.removeToken()
+		return nil, fmt.Errorf("failed to refresh stored token - %w", err)
+	}
+
+	slog.Info("token received", "expiry", tk.Expiry)
+
+	c.set(ctx, tk, true)
+	return contextWithClient(ctx, c), nil
+}
+
+func (c *client) tokenFile() string {
+	return filepath.Join(c.cfg.Store.Directory, tokenFile)
+}
+
+func (c *client) saveToken(tk *oauth2.Token) {
+	data, err := json.Marshal(tk)
+	if err != nil {
+		slog.Warn("failed to save token", "error", err)
+		return
+	}
+
+	name := c.tokenFile()
+	if err := os.WriteFile(name+".tmp", data, 0o600); err != nil {
+		slog.Warn("failed to save token", "error", err)
+		return
+	}
+
+	if err := os.Rename(name+".tmp", name); err != nil {
+		slog.Warn("failed to save token", "error", err)
+	}
+}
+
+func (c *client) removeToken() {
+	if err := os.Remove(c.tokenFile()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("failed to remove token", "error", err)
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
+	"sync/atomic"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/logging"
+	"github.com/kaedwen/trade/pkg/app/metrics"
+)
+
+const attemptContextKey contextKey = "attempt"
+
+// attempt returns the attempt of a request sent by Do, starting with 1.
+func attempt(ctx context.Context) int {
+	if a, ok := ctx.Value(attemptContextKey).(int); ok {
+		return a
+	}
+
+	return 1
+}
+
+// traceTransport records the phases of every request with httptrace and
+// logs them once the response body is closed.
+type traceTransport struct {
+	http.RoundTripper
+	dump bool
+}
+
+// requestTrace collects the timings of one request, the callbacks of
+// httptrace may run on other goroutines.
+type requestTrace struct {
+	start               time.Time
+	dnsStart, dnsDone   atomic.Int64
+	connStart, connDone atomic.Int64
+	tlsStart, tlsDone   atomic.Int64
+	firstByte           atomic.Int64
+	reused              atomic.Bool
+}
+
+func (rt *requestTrace) clientTrace() *httptrace.ClientTrace {
+	now := func(t *atomic.Int64) { t.Store(int64(time.Since(rt.start))) }
+
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { now(&rt.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { now(&rt.dnsDone) },
+		ConnectStart:         func(string, string) { now(&rt.connStart) },
+		ConnectDone:          func(string, string, error) { now(&rt.connDone) },
+		TLSHandshakeStart:    func() { now(&rt.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { now(&rt.tlsDone) },
+		GotConn:              func(ci httptrace.GotConnInfo) { rt.reused.Store(ci.Reused) },
+		GotFirstResponseByte: func() { now(&rt.firstByte) },
+	}
+}
+
+// phase returns the duration between two recorded points, false when the
+// phase did not happen, e.g. on a reused connection.
+func phase(start, done *atomic.Int64) (time.Duration, bool) {
+	s, d := start.Load(), done.Load()
+	if d == 0 {
+		return 0, false
+	}
+
+	return time.Duration(d - s), true
+}
+
+func (tt *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := Endpoint(req.Context())
+	log := slog.With("endpoint", endpoint, "method", req.Method, "request_id", RequestId(req), "attempt", attempt(req.Context()))
+
+	if tt.dump {
+		if d, err := httputil.DumpRequestOut(req, req.GetBody != nil); err == nil {
+			log.Info("api request dump", "dump", logging.Redact(string(d)))
+		}
+	}
+
+	rt := &requestTrace{start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), rt.clientTrace()))
+
+	resp, err := tt.RoundTripper.RoundTrip(req)
+	if err != nil {
+		log.Info("api request trace", append(rt.attrs(endpoint), "error", err)...)
+		return nil, err
+	}
+
+	if tt.dump {
+		if d, err := httputil.DumpResponse(resp, false); err == nil {
+			var head bytes.Buffer
+			body := logging.Body(io.TeeReader(resp.Body, &head))
+			resp.Body = &readCloser{io.MultiReader(&head, resp.Body), resp.Body}
+			log.Info("api response dump", "dump", logging.Redact(string(d)), "body", body)
+		}
+	}
+
+	resp.Body = &tracedBody{ReadCloser: resp.Body, done: func(size int64) {
+		metrics.ApiResponseSize.With(endpoint).Observe(float64(size))
+		log.Info("api request trace", append(rt.attrs(endpoint), "status", resp.StatusCode, "size", size)...)
+	}}
+
+	return resp, nil
+}
+
+// attrs returns the phase durations and records them as metrics.
+func (rt *requestTrace) attrs(endpoint string) []any {
+	args := []any{"reused", rt.reused.Load()}
+
+	for _, p := range []struct {
+		name        string
+		start, done *atomic.Int64
+	}{
+		{"dns", &rt.dnsStart, &rt.dnsDone},
+		{"connect", &rt.connStart, &rt.connDone},
+		{"tls", &rt.tlsStart, &rt.tlsDone},
+		{"first_byte", new(atomic.Int64), &rt.firstByte},
+	} {
+		if d, ok := phase(p.start, p.done); ok {
+			metrics.ApiRequestPhase.With(endpoint, p.name).Observe(d.Seconds())
+			args = append(args, p.name, d)
+		}
+	}
+
+	return append(args, "total", time.Since(rt.start))
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// tracedBody counts the bytes read and reports them once on Close.
+type tracedBody struct {
+	io.ReadCloser
+	size int64
+	done func(int64)
+	once atomic.Bool
+}
+
+func (tb *tracedBody) Read(p []byte) (int, error) {
+	n, err := tb.ReadCloser.Read(p)
+	tb.size += int64(n)
+	return n, err
+}
+
+func (tb *tracedBody) Close() error {
+	err := tb.ReadCloser.Close()
+	if tb.once.CompareAndSwap(false, true) {
+		tb.done(tb.size)
+	}
+
+	return err
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/client"
+	"github.com/kaedwen/trade/pkg/app/control"
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+// controlServer serves the commands of `trade ctl` on the control socket.
+func (a *Application) controlServer(base context.Context) *control.Server {
+	s := control.NewServer(a.cfg.Control.Socket)
+
+	s.Handle("status", func(context.Context, []string) (any, error) {
+		return a.Status(), nil
+	})
+	s.Handle("approve", func(context.Context, []string) (any, error) {
+		return nil, a.relay.Answer("")
+	})
+	s.Handle("tan", func(_ context.Context, args []string) (any, error) {
+		if len(args) != 1 {
+			return nil, ErrArguments
+		}
+		return nil, a.relay.Answer(args[0])
+	})
+	s.Handle("reauth", func(context.Context, []string) (any, error) {
+		return nil, a.startReauth(base)
+	})
+	s.Handle("fetch", a.job(a.scheduler.Trigger))
+	s.Handle("pause", a.job(a.scheduler.Pause))
+	s.Handle("resume", a.job(a.scheduler.Resume))
+	s.Handle("reload", func(context.Context, []string) (any, error) {
+		return nil, a.Reload()
+	})
+	s.Handle("unlock-login", func(context.Context, []string) (any, error) {
+		return nil, a.UnlockLogin(base)
+	})
+	s.Handle("proxy", a.proxy)
+
+	return s
+}
+
+// proxy executes an API request of a CLI invocation with the daemon's
+// session. Only requests to the configured API are accepted, so the token is
+// never sent elsewhere.
+func (a *Application) proxy(ctx context.Context, args []string) (any, error) {
+	if len(args) != 1 {
+		return nil, ErrArguments
+	}
+
+	c, _ := a.loggedIn()
+	if c == nil || !a.Active() {
+		return nil, ErrNotLoggedIn
+	}
+
+	var pr control.ProxyRequest
+	if err := json.Unmarshal([]byte(args[0]), &pr); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(pr.URL, strings.TrimSuffix(a.cfg.ApiAddress.String(), "/")+"/") {
+		return nil, ErrForeignUrl
+	}
+
+	req, err := http.NewRequestWithContext(ctx, pr.Method, pr.URL, bytes.NewReader(pr.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = pr.Header
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set("x-http-request-info", a.NewRequestInfo())
+
+	// a rejected session expires like on failed jobs, which starts a re-login
+	resp, err := c.Do(req, client.WithEndpoint("proxy"))
+	if err != nil {
+		return nil, a.expireOn(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return control.ProxyResponse{Status: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+// Attach uses the session of a running daemon instead of logging in,
+// control.ErrNotRunning when there is none. With wait it waits for the
+// daemon's session to become active, e.g. during its TAN approval.
+func (a *Application) Attach(ctx context.Context, wait bool) (context.Context, error) {
+	c, err := control.Dial(ctx, a.cfg.Control.Socket)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var status Status
+		if err := c.Call(ctx, &status, "status"); err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		if status.Session.Active {
+			break
+		}
+
+		if !wait {
+			c.Close()
+			return nil, ErrDaemonInactive
+		}
+
+		slog.Info("waiting for session of running daemon", "state", status.Session.State)
+
+		select {
+		case <-ctx.Done():
+			c.Close()
+			return nil, ctx.Err()
+		case <-time.After(attachPoll):
+		}
+	}
+
+	slog.Info("using session of running daemon")
+
+	return client.NewContext(ctx, control.NewProxy(c)), nil
+}
+
+const attachPoll = 2 * time.Second
+
+func (a *Application) job(fn func(string) error) control.Handler {
+	return func(_ context.Context, args []string) (any, error) {
+		if len(args) != 1 {
+			return nil, ErrArguments
+		}
+		return nil, fn(args[0])
+	}
+}
+
+// Reload reads the config again and applies alert rules and notification
+// channels. Other settings need a restart.
+func (a *Application) Reload() error {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := a.alerts.Reload(cfg); err != nil {
+		return err
+	}
+
+	if err := a.notify.Reload(cfg); err != nil {
+		return err
+	}
+
+	slog.Info("reloaded alerts and notification channels")
+	return nil
+}
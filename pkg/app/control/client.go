@@ -0,0 +1,76 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type Client struct {
+	mu   sync.Mutex
+	conn net.Conn
+	sc   *bufio.Scanner
+}
+
+// Dial connects to the daemon's control socket, ErrNotRunning when nobody
+// listens on it.
+func Dial(ctx context.Context, path string) (*Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+		return nil, ErrNotRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(nil, 16<<20)
+
+	return &Client{conn: conn, sc: sc}, nil
+}
+
+// Call runs the command and decodes its result into out, unless out is nil.
+func (c *Client) Call(ctx context.Context, out any, command string, args ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dl, _ := ctx.Deadline()
+	c.conn.SetDeadline(dl)
+	defer c.conn.SetDeadline(time.Time{})
+
+	if err := json.NewEncoder(c.conn).Encode(Request{Command: command, Args: args}); err != nil {
+		return err
+	}
+
+	if !c.sc.Scan() {
+		if err := c.sc.Err(); err != nil {
+			return err
+		}
+		return net.ErrClosed
+	}
+
+	var resp Response
+	if err := json.Unmarshal(c.sc.Bytes(), &resp); err != nil {
+		return err
+	}
+
+	if len(resp.Error) > 0 {
+		return errors.New(resp.Error)
+	}
+
+	if out == nil || len(resp.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(resp.Data, out)
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
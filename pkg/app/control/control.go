@@ -0,0 +1,25 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrNotRunning     = errors.New("no daemon running")
+	ErrPeerDenied     = errors.New("peer not allowed")
+	ErrInUse          = errors.New("control socket in use")
+)
+
+// Request is sent as one JSON line per call, the daemon answers each with a
+// Response line.
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+type Response struct {
+	Error string          `json:"error,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
@@ -0,0 +1,90 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/client"
+)
+
+var ErrProxyLogin = errors.New("login is done by the daemon")
+
+// ProxyRequest is an API request executed by the daemon on behalf of a CLI
+// invocation, using the daemon's authenticated session.
+type ProxyRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+type ProxyResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+type proxy struct {
+	c *Client
+}
+
+// NewProxy returns a client sending all requests through the daemon. TAN
+// challenges of write requests are still answered by the caller.
+func NewProxy(c *Client) client.Client {
+	return &proxy{c}
+}
+
+func (p *proxy) Connect(context.Context) (context.Context, error) {
+	return nil, ErrProxyLogin
+}
+
+func (p *proxy) Restore(context.Context) (context.Context, error) {
+	return nil, ErrProxyLogin
+}
+
+func (p *proxy) OAuthSecondFlow(context.Context) (context.Context, error) {
+	return nil, ErrProxyLogin
+}
+
+func (p *proxy) Expiry() time.Time {
+	return time.Time{}
+}
+
+func (p *proxy) Do(req *http.Request, _ ...client.ClientOption) (*http.Response, error) {
+	pr := ProxyRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header,
+	}
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		pr.Body = body
+	}
+
+	data, err := json.Marshal(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ProxyResponse
+	if err := p.c.Call(req.Context(), &resp, "proxy", string(data)); err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:     http.StatusText(resp.Status),
+		StatusCode: resp.Status,
+		Header:     resp.Header,
+		Body:       io.NopCloser(bytes.NewReader(resp.Body)),
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type Handler func(ctx context.Context, args []string) (any, error)
+
+// Server answers requests on a Unix socket. Access is limited by the socket
+// mode (owner only) and the peer credentials of every connection.
+type Server struct {
+	path     string
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewServer(path string) *Server {
+	return &Server{path: path, handlers: make(map[string]Handler)}
+}
+
+func (s *Server) Handle(command string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[command] = h
+}
+
+// Serve listens until the context is done. Handlers get a context deriving
+// from ctx. The caller must hold the instance lock, a stale socket is
+// replaced and only a socket nobody answers on counts as stale.
+func (s *Server) Serve(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	if conn, err := net.Dial("unix", s.path); err == nil {
+		conn.Close()
+		return ErrInUse
+	}
+
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket - %w", err)
+	}
+
+	l, err := net.Listen("unix", s.path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(s.path, 0600); err != nil {
+		l.Close()
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	slog.Info("control socket listening", "path", s.path)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		go s.serve(ctx, conn.(*net.UnixConn))
+	}
+}
+
+func (s *Server) serve(ctx context.Context, conn *net.UnixConn) {
+	defer conn.Close()
+
+	if err := checkPeer(conn); err != nil {
+		slog.Warn("control connection refused", "error", err)
+		json.NewEncoder(conn).Encode(Response{Error: err.Error()})
+		return
+	}
+
+	sc := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+
+	for sc.Scan() {
+		var req Request
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			enc.Encode(Response{Error: err.Error()})
+			return
+		}
+
+		if err := enc.Encode(s.call(ctx, &req)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) call(ctx context.Context, req *Request) Response {
+	s.mu.RLock()
+	h, ok := s.handlers[req.Command]
+	s.mu.RUnlock()
+
+	if !ok {
+		return Response{Error: fmt.Sprintf("%v %q", ErrUnknownCommand, req.Command)}
+	}
+
+	data, err := h(ctx, req.Args)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+
+	return Response{Data: raw}
+}
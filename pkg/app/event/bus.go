@@ -0,0 +1,171 @@
+package event
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Bus interface {
+	Publish(Type, any)
+	Subscribe(context.Context, func(Event), ...Type)
+	SubscribeBounded(context.Context, func(Event), ...Type)
+}
+
+type subscriber struct {
+	types []Type
+	done  <-chan struct{}
+
+	// bounded subscribers have a fixed buffer
+	ch chan Event
+
+	// all others queue without limit
+	mu     sync.Mutex
+	queue  []Event
+	signal chan struct{}
+}
+
+type bus struct {
+	mu   sync.RWMutex
+	id   atomic.Uint64
+	subs []*subscriber
+}
+
+func NewBus() Bus {
+	return &bus{}
+}
+
+// publishWait is the time Publish waits for a bounded subscriber with a full
+// buffer before the event is dropped for it.
+const publishWait = time.Second
+
+// Publish hands the event to all interested subscribers. A bounded subscriber
+// whose buffer stays full misses the event, so a hung stream or notification
+// channel cannot stall the publishers.
+func (b *bus) Publish(t Type, data any) {
+	e := Event{
+		Id:   b.id.Add(1),
+		Type: t,
+		Time: time.Now(),
+		Data: data,
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, s := range b.subs {
+		if len(s.types) > 0 && !slices.Contains(s.types, t) {
+			continue
+		}
+
+		if s.ch == nil {
+			s.push(e)
+			continue
+		}
+
+		select {
+		case s.ch <- e:
+			continue
+		default:
+		}
+
+		timer := time.NewTimer(publishWait)
+		select {
+		case s.ch <- e:
+		case <-s.done:
+		case <-timer.C:
+			slog.Warn("subscriber too slow, event dropped", "event", t, "id", e.Id)
+		}
+		timer.Stop()
+	}
+}
+
+// Subscribe calls fn for every event of the given types (all when empty) until
+// the context is done. Events are delivered in order on a separate goroutine
+// and none is lost, they queue up while fn is busy.
+func (b *bus) Subscribe(ctx context.Context, fn func(Event), types ...Type) {
+	s := &subscriber{
+		types:  types,
+		done:   ctx.Done(),
+		signal: make(chan struct{}, 1),
+	}
+
+	b.add(s)
+
+	go func() {
+		defer b.unsubscribe(s)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.signal:
+				for _, e := range s.pop() {
+					fn(e)
+				}
+			}
+		}
+	}()
+}
+
+// SubscribeBounded is like Subscribe, but events are dropped when fn does not
+// keep up.
+func (b *bus) SubscribeBounded(ctx context.Context, fn func(Event), types ...Type) {
+	s := &subscriber{
+		types: types,
+		done:  ctx.Done(),
+		ch:    make(chan Event, 64),
+	}
+
+	b.add(s)
+
+	go func() {
+		defer b.unsubscribe(s)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-s.ch:
+				fn(e)
+			}
+		}
+	}()
+}
+
+func (b *bus) add(s *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subs = append(b.subs, s)
+}
+
+func (b *bus) unsubscribe(s *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subs = slices.DeleteFunc(b.subs, func(v *subscriber) bool { return v == s })
+}
+
+func (s *subscriber) push(e Event) {
+	s.mu.Lock()
+	s.queue = append(s.queue, e)
+	s.mu.Unlock()
+
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (s *subscriber) pop() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queue
+	s.queue = nil
+	return q
+}
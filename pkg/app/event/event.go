@@ -0,0 +1,136 @@
+package event
+
+import (
+	"time"
+
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+type Type string
+
+const (
+	SnapshotFetched   Type = "SnapshotFetched"
+	BalanceChanged    Type = "BalanceChanged"
+	NewTransaction    Type = "NewTransaction"
+	PositionAdded     Type = "PositionAdded"
+	PositionRemoved   Type = "PositionRemoved"
+	QuantityChanged   Type = "QuantityChanged"
+	PriceMoved        Type = "PriceMoved"
+	DocumentArrived   Type = "DocumentArrived"
+	OrderStateChanged Type = "OrderStateChanged"
+	AlertTriggered    Type = "AlertTriggered"
+	AlertResolved     Type = "AlertResolved"
+	TanRequired       Type = "TanRequired"
+	SessionChanged    Type = "SessionChanged"
+	LoginLocked       Type = "LoginLocked"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+func (s Severity) Level() int {
+	switch s {
+	case SeverityCritical:
+		return 2
+	case SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type Event struct {
+	Id   uint64    `json:"id"`
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+type OrderStateChange struct {
+	DepotId          string                `json:"depotId"`
+	OrderId          string                `json:"orderId"`
+	ParentOrderId    string                `json:"parentOrderId,omitempty"`
+	InstrumentId     string                `json:"instrumentId"`
+	Side             model.OrderSide       `json:"side"`
+	From             model.OrderStatus     `json:"from,omitempty"`
+	To               model.OrderStatus     `json:"to"`
+	Quantity         float64               `json:"quantity"`
+	ExecutedQuantity float64               `json:"executedQuantity"`
+	AveragePrice     *model.Amount         `json:"averagePrice,omitempty"`
+	LastExecution    *model.OrderExecution `json:"lastExecution,omitempty"`
+}
+
+// Snapshot is the complete result of one account fetch.
+type Snapshot struct {
+	Time         time.Time                      `json:"time"`
+	Balances     *model.AllBalancesResponse     `json:"balances"`
+	Positions    []model.Position               `json:"positions"`
+	Transactions map[string][]model.Transaction `json:"transactions"`
+}
+
+type BalanceChange struct {
+	ProductId   string            `json:"productId"`
+	ProductType model.ProductType `json:"productType"`
+	Previous    model.Amount      `json:"previous"`
+	Current     model.Amount      `json:"current"`
+}
+
+type TransactionArrival struct {
+	AccountId   string            `json:"accountId"`
+	Transaction model.Transaction `json:"transaction"`
+}
+
+type PositionChange struct {
+	DepotId      string            `json:"depotId"`
+	InstrumentId string            `json:"instrumentId"`
+	Instrument   *model.Instrument `json:"instrument,omitempty"`
+	Quantity     model.Amount      `json:"quantity"`
+	Value        model.Amount      `json:"value"`
+	// Previous is the quantity before a partial sale or purchase.
+	Previous *model.Amount `json:"previous,omitempty"`
+}
+
+type PriceMove struct {
+	DepotId      string            `json:"depotId"`
+	InstrumentId string            `json:"instrumentId"`
+	Instrument   *model.Instrument `json:"instrument,omitempty"`
+	Previous     model.Amount      `json:"previous"`
+	Current      model.Amount      `json:"current"`
+	// Change is relative to the previously reported price in percent.
+	Change float64 `json:"change"`
+}
+
+type DocumentArrival struct {
+	Document model.Document `json:"document"`
+}
+
+type Alert struct {
+	Rule      string   `json:"rule"`
+	Key       string   `json:"key"`
+	Severity  Severity `json:"severity"`
+	Message   string   `json:"message"`
+	Value     float64  `json:"value"`
+	Threshold float64  `json:"threshold"`
+}
+
+// TanChallenge carries what is needed to answer the challenge: the photoTAN
+// image (base64 PNG) for P_TAN, the masked phone number for M_TAN.
+type TanChallenge struct {
+	Id        string `json:"id"`
+	Type      string `json:"type"`
+	Challenge string `json:"challenge,omitempty"`
+}
+
+type SessionState struct {
+	State string `json:"state"`
+}
+
+type LoginLock struct {
+	Error    string `json:"error"`
+	Failures int    `json:"failures"`
+}
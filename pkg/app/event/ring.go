@@ -0,0 +1,82 @@
+package event
+
+import (
+	"context"
+	"sync"
+)
+
+// Ring keeps the latest events in a bounded buffer, so stream consumers can
+// resume after a reconnect.
+type Ring struct {
+	mu        sync.Mutex
+	events    []Event
+	size      int
+	last      uint64
+	listeners map[chan Event]struct{}
+}
+
+func NewRing(size int) *Ring {
+	return &Ring{size: size, listeners: make(map[chan Event]struct{})}
+}
+
+// Subscribe records all events of the given types (all when empty).
+func (r *Ring) Subscribe(ctx context.Context, bus Bus, types ...Type) {
+	bus.SubscribeBounded(ctx, r.add, types...)
+}
+
+func (r *Ring) add(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.last = max(r.last, e.Id)
+
+	if r.size > 0 {
+		if len(r.events) == r.size {
+			r.events = r.events[1:]
+		}
+		r.events = append(r.events, e)
+	}
+
+	for ch := range r.listeners {
+		select {
+		case ch <- e:
+		default:
+			// a listener not keeping up is dropped, it resumes by its last id
+			delete(r.listeners, ch)
+			close(ch)
+		}
+	}
+}
+
+// Listen returns the buffered events after lastId and a channel receiving all
+// later events. The channel is closed when the listener falls behind or
+// cancel is called. An unknown lastId, e.g. from before a restart, replays
+// the whole buffer. The returned cursor is the id the listener continues
+// from.
+func (r *Ring) Listen(lastId uint64) (cursor uint64, backlog []Event, ch <-chan Event, cancel func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if lastId > r.last {
+		lastId = 0
+	}
+
+	for _, e := range r.events {
+		if e.Id > lastId {
+			backlog = append(backlog, e)
+		}
+	}
+
+	c := make(chan Event, 64)
+	r.listeners[c] = struct{}{}
+
+	return lastId, backlog, c, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if _, ok := r.listeners[c]; ok {
+			delete(r.listeners, c)
+			close(c)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var ErrHeld = errors.New("another instance is running")
+
+// Holder describes the process holding the lock.
+type Holder struct {
+	Pid     int       `json:"pid"`
+	Started time.Time `json:"started"`
+	Command string    `json:"command"`
+}
+
+type HeldError struct {
+	Holder Holder
+}
+
+func (e *HeldError) Error() string {
+	if e.Holder.Pid == 0 {
+		return ErrHeld.Error()
+	}
+
+	return fmt.Sprintf("%v (pid %d %q, started %s)", ErrHeld, e.Holder.Pid, e.Holder.Command, e.Holder.Started.Format(time.DateTime))
+}
+
+func (e *HeldError) Unwrap() error {
+	return ErrHeld
+}
+
+func self() Holder {
+	cmd := ""
+	if len(os.Args) > 0 {
+		cmd = os.Args[0]
+		if len(os.Args) > 1 {
+			cmd += " " + os.Args[1]
+		}
+	}
+
+	return Holder{Pid: os.Getpid(), Started: time.Now(), Command: cmd}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package instance
+
+// Lock is not supported without flock, concurrent instances are not
+// detected.
+type Lock struct{}
+
+func Acquire(path string) (*Lock, error) {
+	return &Lock{}, nil
+}
+
+func (l *Lock) Release() error {
+	return nil
+}
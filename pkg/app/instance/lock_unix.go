@@ -0,0 +1,59 @@
+//go:build unix
+
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Lock is held for the lifetime of the process, the kernel releases it when
+// the process exits.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes the lock file at path without waiting, a HeldError names the
+// holder if another process has it.
+func Acquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		defer f.Close()
+
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			var h Holder
+			json.NewDecoder(f).Decode(&h)
+			return nil, &HeldError{h}
+		}
+
+		return nil, err
+	}
+
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := json.NewEncoder(f).Encode(self()); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Lock{f}, nil
+}
+
+func (l *Lock) Release() error {
+	l.f.Truncate(0)
+	return l.f.Close()
+}
@@ -0,0 +1,132 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/client"
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"golang.org/x/oauth2"
+)
+
+const stateName = "login"
+
+var ErrLocked = errors.New("password login is locked after invalid credentials, check the config and run `trade ctl unlock-login`")
+
+// State is persisted, so a restart does not retry a rejected PIN.
+type State struct {
+	Locked          bool      `json:"locked"`
+	Failures        int       `json:"failures"`
+	NetworkFailures int       `json:"networkFailures"`
+	LastError       string    `json:"lastError,omitempty"`
+	LastAttempt     time.Time `json:"lastAttempt,omitempty"`
+	LockedAt        time.Time `json:"lockedAt,omitempty"`
+}
+
+// Guard protects the account from being locked by comdirect after repeated
+// wrong PINs. A single response rejecting the credentials locks further
+// password grants until an operator unlocks them, network and server errors
+// are only counted.
+type Guard struct {
+	store store.Store
+	bus   event.Bus
+	mu    sync.Mutex
+}
+
+func NewGuard(st store.Store, bus event.Bus) *Guard {
+	return &Guard{store: st, bus: bus}
+}
+
+// State reads the state from the store, it may have been changed by another
+// process.
+func (g *Guard) State() (State, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.load()
+}
+
+// Unlock clears the lock and the failure counters.
+func (g *Guard) Unlock() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	slog.Info("password login unlocked")
+	return g.store.Save(stateName, State{})
+}
+
+func (g *Guard) load() (State, error) {
+	var s State
+	if err := g.store.Load(stateName, &s); err != nil && !errors.Is(err, store.ErrNoState) {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// Wrap guards the password grant done by Connect of c.
+func (g *Guard) Wrap(c client.Client) client.Client {
+	return &guarded{c, g}
+}
+
+type guarded struct {
+	client.Client
+	g *Guard
+}
+
+func (gc *guarded) Connect(ctx context.Context) (context.Context, error) {
+	gc.g.mu.Lock()
+	defer gc.g.mu.Unlock()
+
+	s, err := gc.g.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Locked {
+		return nil, ErrLocked
+	}
+
+	ctx, err = gc.Client.Connect(ctx)
+	s.LastAttempt = time.Now()
+
+	switch {
+	case err == nil:
+		s.NetworkFailures = 0
+		s.LastError = ""
+	case credentialError(err):
+		s.Failures++
+		s.Locked = true
+		s.LockedAt = s.LastAttempt
+		s.LastError = err.Error()
+
+		slog.Error("credentials rejected, password login locked", "error", err)
+		gc.g.bus.Publish(event.LoginLocked, event.LoginLock{Error: s.LastError, Failures: s.Failures})
+	default:
+		s.NetworkFailures++
+		s.LastError = err.Error()
+	}
+
+	if serr := gc.g.store.Save(stateName, s); serr != nil {
+		return nil, errors.Join(err, serr)
+	}
+
+	return ctx, err
+}
+
+// credentialError tells a rejected password grant from network and server
+// errors, which are worth retrying.
+func credentialError(err error) bool {
+	var re *oauth2.RetrieveError
+	if !errors.As(err, &re) || re.Response == nil {
+		return false
+	}
+
+	code := re.Response.StatusCode
+	return code >= 400 && code < 500 && code != http.StatusTooManyRequests && code != http.StatusRequestTimeout
+}
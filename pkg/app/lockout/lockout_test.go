@@ -0,0 +1,149 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kaedwen/trade/pkg/app/client"
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"golang.org/x/oauth2"
+)
+
+func retrieveError(status int) error {
+	return fmt.Errorf("failed to connect - %w", &oauth2.RetrieveError{Response: &http.Response{StatusCode: status}})
+}
+
+func TestCredentialError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"bad request", retrieveError(http.StatusBadRequest), true},
+		{"unauthorized", retrieveError(http.StatusUnauthorized), true},
+		{"too many requests", retrieveError(http.StatusTooManyRequests), false},
+		{"request timeout", retrieveError(http.StatusRequestTimeout), false},
+		{"server error", retrieveError(http.StatusServiceUnavailable), false},
+		{"no response", &oauth2.RetrieveError{}, false},
+		{"network error", errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := credentialError(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeClient answers password grants with the next of errs.
+type fakeClient struct {
+	client.Client
+	errs  []error
+	calls int
+}
+
+func (c *fakeClient) Connect(ctx context.Context) (context.Context, error) {
+	err := c.errs[c.calls]
+	c.calls++
+
+	return ctx, err
+}
+
+type recordingBus struct {
+	published int
+}
+
+func (b *recordingBus) Publish(event.Type, any) {
+	b.published++
+}
+
+func (b *recordingBus) Subscribe(context.Context, func(event.Event), ...event.Type) {}
+
+func (b *recordingBus) SubscribeBounded(context.Context, func(event.Event), ...event.Type) {}
+
+func TestGuard(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		attempts  int
+		wantCalls int
+		want      State
+	}{
+		{
+			name:      "success",
+			errs:      []error{nil},
+			attempts:  1,
+			wantCalls: 1,
+		},
+		{
+			name:      "success resets network errors",
+			errs:      []error{errors.New("connection refused"), retrieveError(http.StatusBadGateway), nil},
+			attempts:  3,
+			wantCalls: 3,
+		},
+		{
+			name:      "network errors are counted",
+			errs:      []error{errors.New("connection refused"), retrieveError(http.StatusBadGateway)},
+			attempts:  2,
+			wantCalls: 2,
+			want:      State{NetworkFailures: 2},
+		},
+		{
+			name:      "rejected credentials lock",
+			errs:      []error{retrieveError(http.StatusUnauthorized)},
+			attempts:  3,
+			wantCalls: 1,
+			want:      State{Locked: true, Failures: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := store.Open(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer st.Close()
+
+			c := &fakeClient{errs: tt.errs}
+			bus := &recordingBus{}
+			g := NewGuard(st, bus)
+			gc := g.Wrap(c)
+
+			for i := 0; i < tt.attempts; i++ {
+				_, err := gc.Connect(context.Background())
+				if i >= tt.wantCalls && !errors.Is(err, ErrLocked) {
+					t.Errorf("attempt %d: got error %v, want %v", i, err, ErrLocked)
+				}
+			}
+
+			if c.calls != tt.wantCalls {
+				t.Errorf("got %d password grants, want %d", c.calls, tt.wantCalls)
+			}
+
+			s, err := g.State()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Locked != tt.want.Locked || s.Failures != tt.want.Failures || s.NetworkFailures != tt.want.NetworkFailures {
+				t.Errorf("got state %+v, want %+v", s, tt.want)
+			}
+			if s.Locked && bus.published != 1 {
+				t.Errorf("got %d events, want one", bus.published)
+			}
+
+			if err := g.Unlock(); err != nil {
+				t.Fatal(err)
+			}
+			if s, _ := g.State(); s.Locked {
+				t.Errorf("still locked after unlock")
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Package logging sets up the structured logger of the application, every
+// record passes the redaction of secrets and personal data.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+// bodyLimit is the number of bytes of a response body logged at most.
+const bodyLimit = 2048
+
+// Setup installs the default logger as configured, which also receives the
+// output of the log package. The secrets of the config are masked.
+func Setup(cfg *config.Config) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+		return fmt.Errorf("failed to parse log level - %w", err)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch strings.ToLower(cfg.Log.Format) {
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	case "", "text":
+		h = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", cfg.Log.Format)
+	}
+
+	Secret(cfg.AccountId, cfg.Pin, cfg.ClientSecret, cfg.Http.Token)
+	for _, ch := range cfg.Notifications.Channels {
+		Secret(ch.Token, ch.Secret, ch.SMTP.Password)
+	}
+
+	slog.SetDefault(slog.New(&redactHandler{h}))
+	return nil
+}
+
+// Body returns the beginning of a response body for logging, redacted.
+func Body(r io.Reader) string {
+	d, _ := io.ReadAll(io.LimitReader(r, bodyLimit))
+	return Redact(string(d))
+}
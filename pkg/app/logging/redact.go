@@ -0,0 +1,154 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const mask = "***"
+
+var (
+	mu sync.RWMutex
+	// secrets are keyed, so replaced values like the session id do not pile up
+	secrets = make(map[string]string)
+)
+
+// sensitiveKeys are attribute keys whose values are never logged.
+var sensitiveKeys = []string{"pin", "password", "secret", "token", "authorization", "session_id", "iban"}
+
+// numberKeys are attribute keys holding account, depot or customer numbers,
+// only their last four digits are logged.
+var numberKeys = []string{"account", "depot", "customer", "product"}
+
+// number masks all but the last four digits of a number.
+var number = regexp.MustCompile(`\b\d{4,8}(\d{4})\b`)
+
+var patterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// secrets, TANs and session ids in json and form encoded bodies
+	{regexp.MustCompile(`"(access_token|refresh_token|client_secret|password|pin|token|tan|sessionId|identifier)"\s*:\s*"[^"]*"`), `"$1":"` + mask + `"`},
+	{regexp.MustCompile(`\b(access_token|refresh_token|client_secret|password|username|token)=[^&\s]+`), `$1=` + mask},
+	{regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`), `Bearer ` + mask},
+	// IBANs, compact and printed in groups of four
+	{regexp.MustCompile(`\b[A-Z]{2}\d{2}(?:[A-Z0-9]{11,30}|(?: [A-Z0-9]{4}){3,7}(?: [A-Z0-9]{1,4})?)\b`), mask},
+	// account, depot and customer numbers in json bodies, the last digits are
+	// kept to tell them apart
+	{regexp.MustCompile(`"(accountDisplayId|depotDisplayId|customerNumber|kdnr)"\s*:\s*"[^"]*?(\w{0,4})"`), `"$1":"` + mask + `$2"`},
+}
+
+// Secret registers values masked wherever they appear in log records, e.g.
+// the PIN.
+func Secret(values ...string) {
+	for _, v := range values {
+		SetSecret(v, v)
+	}
+}
+
+// SetSecret masks value in log records instead of the value registered
+// before under the same key, e.g. the id of the current session.
+func SetSecret(key, value string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	// short values would mask unrelated text
+	if len(value) < 4 {
+		delete(secrets, key)
+		return
+	}
+
+	secrets[key] = value
+}
+
+// Redact masks registered secrets and known patterns of personal data.
+func Redact(s string) string {
+	mu.RLock()
+	for _, v := range secrets {
+		s = strings.ReplaceAll(s, v, mask)
+	}
+	mu.RUnlock()
+
+	for _, p := range patterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+
+	return s
+}
+
+// redactHandler redacts message and attributes before handing the record to
+// the wrapped handler.
+type redactHandler struct {
+	slog.Handler
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+
+	return &redactHandler{h.Handler.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+
+	if matchKey(a.Key, sensitiveKeys) {
+		return slog.String(a.Key, mask)
+	}
+
+	switch v.Kind() {
+	case slog.KindString:
+		if matchKey(a.Key, numberKeys) {
+			return slog.String(a.Key, number.ReplaceAllString(Redact(v.String()), mask+`$1`))
+		}
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	case slog.KindAny:
+		// errors and other values are logged by their text
+		if e, ok := v.Any().(error); ok {
+			return slog.String(a.Key, Redact(e.Error()))
+		}
+		return slog.String(a.Key, Redact(fmt.Sprint(v.Any())))
+	}
+
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// matchKey reports whether the attribute key is one of keys or ends with
+// one of them, e.g. "refresh_token".
+func matchKey(key string, keys []string) bool {
+	key = strings.ToLower(key)
+	for _, k := range keys {
+		if key == k || strings.HasSuffix(key, "_"+k) {
+			return true
+		}
+	}
+
+	return false
+}
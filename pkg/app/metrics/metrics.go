@@ -0,0 +1,34 @@
+package metrics
+
+var (
+	AccountBalance = NewGaugeVec("trade_account_balance",
+		"Balance of an account or card.", "account_id", "type", "currency")
+	AccountAvailableCash = NewGaugeVec("trade_account_available_cash",
+		"Available cash amount of an account.", "account_id", "type", "currency")
+	DepotValue = NewGaugeVec("trade_depot_value",
+		"Current value of a depot.", "depot_id", "currency")
+	PositionValue = NewGaugeVec("trade_position_value",
+		"Current value of a depot position.", "depot_id", "instrument_id", "wkn", "currency")
+	PositionProfitLoss = NewGaugeVec("trade_position_profit_loss",
+		"Absolute profit or loss of a depot position against its purchase value.", "depot_id", "instrument_id", "wkn", "currency")
+
+	ApiRequests = NewCounterVec("trade_api_requests_total",
+		"Requests sent to the comdirect API.", "endpoint", "method", "status")
+	ApiRequestDuration = NewHistogramVec("trade_api_request_duration_seconds",
+		"Latency of requests sent to the comdirect API.", DefaultBuckets, "endpoint", "method")
+	ApiRetries = NewCounterVec("trade_api_retries_total",
+		"Requests to the comdirect API retried because of their status.", "endpoint", "status")
+	ApiRateLimitWaits = NewCounterVec("trade_api_rate_limit_waits_total",
+		"Waits caused by rate limited (429) responses.", "endpoint")
+	ApiRequestPhase = NewHistogramVec("trade_api_request_phase_seconds",
+		"Duration of the phases of traced requests (dns, connect, tls, first_byte).", DefaultBuckets, "endpoint", "phase")
+	ApiResponseSize = NewHistogramVec("trade_api_response_size_bytes",
+		"Size of the bodies of traced responses.", SizeBuckets, "endpoint")
+
+	TokenExpiry = NewGaugeVec("trade_token_expiry_timestamp_seconds",
+		"Expiry of the current access token.")
+	SessionState = NewGaugeVec("trade_session_state",
+		"State of the comdirect session, 1 for the current state.", "state")
+	JobLastSuccess = NewGaugeVec("trade_job_last_success_timestamp_seconds",
+		"Time of the last successful run of a scheduler job.", "job")
+)
@@ -0,0 +1,241 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type kind string
+
+const (
+	kindGauge     kind = "gauge"
+	kindCounter   kind = "counter"
+	kindHistogram kind = "histogram"
+)
+
+// Registry holds metric families and writes them in the Prometheus text
+// exposition format.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+var Default = &Registry{}
+
+type family struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labels []string
+	value  float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type GaugeVec struct{ f *family }
+type CounterVec struct{ f *family }
+type HistogramVec struct{ f *family }
+
+type Gauge struct{ s *series }
+type Counter struct{ s *series }
+type Histogram struct {
+	f *family
+	s *series
+}
+
+var DefaultBuckets = []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+var SizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f.series = make(map[string]*series)
+	r.families = append(r.families, f)
+	return f
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{Default.register(&family{name: name, help: help, kind: kindGauge, labels: labels})}
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{Default.register(&family{name: name, help: help, kind: kindCounter, labels: labels})}
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{Default.register(&family{name: name, help: help, kind: kindHistogram, labels: labels, buckets: buckets})}
+}
+
+func (f *family) with(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d labels, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.Join(values, "\x00")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: slices.Clone(values), counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+func (f *family) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	clear(f.series)
+}
+
+func (g *GaugeVec) With(values ...string) Gauge {
+	return Gauge{g.f.with(values)}
+}
+
+// Reset drops all series, e.g. before setting the values of a new snapshot.
+func (g *GaugeVec) Reset() {
+	g.f.reset()
+}
+
+func (c *CounterVec) With(values ...string) Counter {
+	return Counter{c.f.with(values)}
+}
+
+func (h *HistogramVec) With(values ...string) Histogram {
+	return Histogram{h.f, h.f.with(values)}
+}
+
+func (g Gauge) Set(v float64) {
+	lock(func() { g.s.value = v })
+}
+
+func (g Gauge) SetTime(t time.Time) {
+	g.Set(float64(t.Unix()))
+}
+
+func (c Counter) Inc() {
+	c.Add(1)
+}
+
+func (c Counter) Add(v float64) {
+	lock(func() { c.s.value += v })
+}
+
+func (h Histogram) Observe(v float64) {
+	lock(func() {
+		for i, b := range h.f.buckets {
+			if v <= b {
+				h.s.counts[i]++
+			}
+		}
+		h.s.sum += v
+		h.s.count++
+	})
+}
+
+var seriesMu sync.Mutex
+
+func lock(fn func()) {
+	seriesMu.Lock()
+	defer seriesMu.Unlock()
+	fn()
+}
+
+// WriteTo writes all metrics in the text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	all := make([]*series, 0, len(keys))
+	for _, k := range keys {
+		all = append(all, f.series[k])
+	}
+	f.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+
+	seriesMu.Lock()
+	defer seriesMu.Unlock()
+
+	for _, s := range all {
+		if f.kind != kindHistogram {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, labels(f.labels, s.labels), format(s.value))
+			continue
+		}
+
+		for i, le := range f.buckets {
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labels(append(slices.Clone(f.labels), "le"), append(slices.Clone(s.labels), format(le))), s.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labels(append(slices.Clone(f.labels), "le"), append(slices.Clone(s.labels), "+Inf")), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, labels(f.labels, s.labels), format(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, labels(f.labels, s.labels), s.count)
+	}
+}
+
+func labels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, n := range names {
+		pairs[i] = n + "=" + strconv.Quote(values[i])
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func format(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
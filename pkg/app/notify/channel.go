@@ -0,0 +1,279 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+const (
+	KindWebhook = "webhook"
+	KindNtfy    = "ntfy"
+	KindGotify  = "gotify"
+	KindEmail   = "email"
+	KindCommand = "command"
+)
+
+const HeaderSignature = "X-Trade-Signature"
+
+type Notifier interface {
+	Notify(context.Context, *Message) error
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+func newNotifier(c config.Channel) (Notifier, error) {
+	switch c.Kind {
+	case KindWebhook:
+		if len(c.URL) == 0 {
+			return nil, errors.New("webhook requires an url")
+		}
+		return &webhook{c.URL, []byte(c.Secret)}, nil
+	case KindNtfy:
+		if len(c.URL) == 0 {
+			return nil, errors.New("ntfy requires an url")
+		}
+		return &ntfy{c.URL, c.Token}, nil
+	case KindGotify:
+		if len(c.URL) == 0 || len(c.Token) == 0 {
+			return nil, errors.New("gotify requires an url and a token")
+		}
+		return &gotify{strings.TrimSuffix(c.URL, "/") + "/message", c.Token}, nil
+	case KindEmail:
+		if len(c.SMTP.Address) == 0 || len(c.SMTP.To) == 0 {
+			return nil, errors.New("email requires a smtp address and recipients")
+		}
+		return &email{c.SMTP}, nil
+	case KindCommand:
+		if len(c.Command) == 0 {
+			return nil, errors.New("command requires a command")
+		}
+		return &command{c.Command}, nil
+	default:
+		return nil, fmt.Errorf("unknown channel kind %q", c.Kind)
+	}
+}
+
+// webhook posts the message as JSON, signed with HMAC-SHA256 over the body
+// when a secret is configured.
+type webhook struct {
+	url    string
+	secret []byte
+}
+
+func (w *webhook) Notify(ctx context.Context, m *Message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(data)
+		req.Header.Set(HeaderSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	return send(req)
+}
+
+type ntfy struct {
+	url   string
+	token string
+}
+
+func (n *ntfy) Notify(ctx context.Context, m *Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, strings.NewReader(m.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", m.Title)
+	req.Header.Set("Tags", string(m.Type))
+	req.Header.Set("Priority", fmt.Sprint([]int{3, 4, 5}[m.Severity.Level()]))
+	if len(n.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	return send(req)
+}
+
+type gotify struct {
+	url   string
+	token string
+}
+
+func (g *gotify) Notify(ctx context.Context, m *Message) error {
+	data, _ := json.Marshal(map[string]any{
+		"title":    m.Title,
+		"message":  m.Body,
+		"priority": []int{4, 6, 9}[m.Severity.Level()],
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.token)
+
+	return send(req)
+}
+
+// smtpTimeout bounds the whole conversation with the mail server.
+const smtpTimeout = 30 * time.Second
+
+type email struct {
+	cfg config.SMTP
+}
+
+func (e *email) Notify(ctx context.Context, m *Message) error {
+	var auth smtp.Auth
+	if len(e.cfg.Username) > 0 {
+		host, _, _ := strings.Cut(e.cfg.Address, ":")
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: [trade] %s\r\n", m.Title)
+	fmt.Fprintf(&b, "Date: %s\r\n", m.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s\r\n\r\nseverity: %s\r\nevent: %s\r\n", m.Body, m.Severity, m.Type)
+
+	return e.send(ctx, auth, []byte(b.String()))
+}
+
+// send does what smtp.SendMail does, but within smtpTimeout and aborted when
+// the context is done.
+func (e *email) send(ctx context.Context, auth smtp.Auth, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", e.cfg.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	host, _, _ := strings.Cut(e.cfg.Address, ":")
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(e.cfg.From); err != nil {
+		return err
+	}
+
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// command runs a local hook with the message in its environment and as JSON
+// on stdin.
+type command struct {
+	args []string
+}
+
+func (c *command) Notify(ctx context.Context, m *Message) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"TRADE_EVENT="+string(m.Type),
+		"TRADE_SEVERITY="+string(m.Severity),
+		"TRADE_TITLE="+m.Title,
+		"TRADE_BODY="+m.Body,
+	)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w - %s", err, bytes.TrimSpace(out))
+	}
+
+	return nil
+}
+
+func send(req *http.Request) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification rejected with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func severity(s string) event.Severity {
+	return event.Severity(strings.ToLower(s))
+}
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/tan"
+)
+
+type Message struct {
+	Type     event.Type      `json:"type"`
+	Severity event.Severity  `json:"severity"`
+	Title    string          `json:"title"`
+	Body     string          `json:"body"`
+	Time     time.Time       `json:"time"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// key identifies equal messages for deduplication.
+func (m *Message) key() string {
+	h := sha256.Sum256([]byte(string(m.Type) + "\x00" + m.Title + "\x00" + m.Body))
+	return hex.EncodeToString(h[:])
+}
+
+// messageFor formats an event, snapshots are not notified.
+func messageFor(e event.Event) (*Message, bool) {
+	m := &Message{Type: e.Type, Severity: event.SeverityInfo, Time: e.Time}
+
+	switch data := e.Data.(type) {
+	case event.TanChallenge:
+		m.Severity = event.SeverityCritical
+		m.Title = "TAN approval needed"
+		switch data.Type {
+		case tan.TypePushTan:
+			m.Body = fmt.Sprintf("please approve the %s challenge %s", data.Type, data.Id)
+		case tan.TypeMobileTan:
+			m.Body = fmt.Sprintf("please answer the %s challenge %s with `trade ctl tan <code>`, the TAN was sent to %s", data.Type, data.Id, data.Challenge)
+		default:
+			// the photoTAN image is too large for a message, it is shown on the dashboard
+			m.Body = fmt.Sprintf("please answer the %s challenge %s with `trade ctl tan <code>`, the image is shown on the dashboard", data.Type, data.Id)
+		}
+	case event.LoginLock:
+		m.Severity = event.SeverityCritical
+		m.Title = "login locked"
+		m.Body = fmt.Sprintf("comdirect rejected the credentials (%s), no further logins are attempted until `trade ctl unlock-login`", data.Error)
+	case event.Alert:
+		m.Severity = data.Severity
+		m.Title = fmt.Sprintf("alert %s", data.Rule)
+		if e.Type == event.AlertResolved {
+			m.Severity = event.SeverityInfo
+			m.Title = fmt.Sprintf("alert %s resolved", data.Rule)
+		}
+		m.Body = data.Message
+	case event.OrderStateChange:
+		m.Title = fmt.Sprintf("order %s %s", data.OrderId, data.To)
+		m.Body = fmt.Sprintf("%s %v of %v %s executed", data.Side, data.ExecutedQuantity, data.Quantity, data.InstrumentId)
+		if data.AveragePrice != nil {
+			m.Body += fmt.Sprintf(" at %v %s", data.AveragePrice.Value, data.AveragePrice.Unit)
+		}
+	case event.BalanceChange:
+		m.Title = fmt.Sprintf("balance of %s changed", data.ProductId)
+		m.Body = fmt.Sprintf("%.2f %s (was %.2f)", data.Current.Value, data.Current.Unit, data.Previous.Value)
+	case event.TransactionArrival:
+		m.Title = fmt.Sprintf("new transaction on %s", data.AccountId)
+		m.Body = fmt.Sprintf("%.2f %s %s %s", data.Transaction.Amount.Value, data.Transaction.Amount.Unit, data.Transaction.TransactionType.Text, data.Transaction.RemittanceInfo)
+	case event.PositionChange:
+		m.Title = fmt.Sprintf("%s %s", e.Type, data.InstrumentId)
+		m.Body = fmt.Sprintf("depot %s, quantity %v, value %.2f %s", data.DepotId, data.Quantity.Value, data.Value.Value, data.Value.Unit)
+		if data.Previous != nil {
+			m.Body = fmt.Sprintf("depot %s, quantity %v (was %v), value %.2f %s", data.DepotId, data.Quantity.Value, data.Previous.Value, data.Value.Value, data.Value.Unit)
+		}
+	case event.PriceMove:
+		m.Title = fmt.Sprintf("price of %s moved %.2f%%", data.InstrumentId, data.Change)
+		m.Body = fmt.Sprintf("%v %s (was %v)", data.Current.Value, data.Current.Unit, data.Previous.Value)
+	case event.DocumentArrival:
+		m.Title = "new postbox document"
+		m.Body = data.Document.Name
+	default:
+		return nil, false
+	}
+
+	m.Data, _ = json.Marshal(e.Data)
+
+	return m, true
+}
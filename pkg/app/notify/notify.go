@@ -0,0 +1,273 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+const stateName = "notifications"
+
+// Dispatcher routes events to the configured channels. Messages failing to
+// deliver or arriving during quiet hours are kept in a persistent queue and
+// delivered later.
+type Dispatcher struct {
+	cfg      *config.Notifications
+	bus      event.Bus
+	store    store.Store
+	channels map[string]*channel
+
+	mu    sync.Mutex
+	sent  map[string]time.Time
+	queue []queued
+}
+
+type channel struct {
+	Notifier
+	name        string
+	minSeverity event.Severity
+	types       []event.Type
+	quiet       *quietHours
+}
+
+type queued struct {
+	Channel   string    `json:"channel"`
+	Message   *Message  `json:"message"`
+	Attempts  int       `json:"attempts"`
+	NotBefore time.Time `json:"notBefore"`
+}
+
+func NewDispatcher(cfg *config.Config, bus event.Bus, st store.Store) (*Dispatcher, error) {
+	d := &Dispatcher{
+		cfg:   &cfg.Notifications,
+		bus:   bus,
+		store: st,
+		sent:  make(map[string]time.Time),
+	}
+
+	var err error
+	if d.channels, err = newChannels(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := st.Load(stateName, &d.queue); err != nil && !errors.Is(err, store.ErrNoState) {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func newChannels(cfg *config.Config) (map[string]*channel, error) {
+	channels := make(map[string]*channel)
+
+	for i, c := range cfg.Notifications.Channels {
+		name := c.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("%s-%d", c.Kind, i)
+		}
+
+		n, err := newNotifier(c)
+		if err != nil {
+			return nil, fmt.Errorf("notification channel %s - %w", name, err)
+		}
+
+		q, err := newQuietHours(c.QuietHours)
+		if err != nil {
+			return nil, fmt.Errorf("notification channel %s - %w", name, err)
+		}
+
+		ch := &channel{Notifier: n, name: name, minSeverity: severity(c.MinSeverity), quiet: q}
+		for _, t := range c.Types {
+			ch.types = append(ch.types, event.Type(t))
+		}
+
+		channels[name] = ch
+	}
+
+	return channels, nil
+}
+
+// Reload replaces the channels and their routing. Queued messages for
+// channels no longer configured are dropped on their next retry.
+func (d *Dispatcher) Reload(cfg *config.Config) error {
+	channels, err := newChannels(cfg)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.channels = channels
+	return nil
+}
+
+// Subscribe delivers events until the context is done.
+func (d *Dispatcher) Subscribe(ctx context.Context) {
+	d.bus.SubscribeBounded(ctx, func(e event.Event) {
+		if m, ok := messageFor(e); ok {
+			d.Dispatch(ctx, m)
+		}
+	})
+
+	go d.retry(ctx)
+}
+
+// Dispatch sends the message to all channels routing it. The channels are
+// called outside of the lock, so a slow one does not block the others.
+func (d *Dispatcher) Dispatch(ctx context.Context, m *Message) {
+	d.mu.Lock()
+	d.expire()
+
+	var targets []*channel
+	for _, ch := range d.channels {
+		if !ch.routes(m) || d.duplicate(ch, m) {
+			continue
+		}
+
+		if m.Severity != event.SeverityCritical && ch.quiet != nil {
+			if end, ok := ch.quiet.until(time.Now()); ok {
+				d.enqueue(ch, m, 0, end)
+				continue
+			}
+		}
+
+		targets = append(targets, ch)
+	}
+
+	d.save()
+	d.mu.Unlock()
+
+	var failed []*channel
+	for _, ch := range targets {
+		if err := ch.Notify(ctx, m); err != nil {
+			slog.Warn("failed to notify", "channel", ch.name, "error", err)
+			failed = append(failed, ch)
+		}
+	}
+
+	if len(failed) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, ch := range failed {
+		d.enqueue(ch, m, 1, time.Now().Add(d.cfg.RetryInterval.Duration))
+	}
+	d.save()
+}
+
+func (d *Dispatcher) retry(ctx context.Context) {
+	t := time.NewTicker(d.cfg.RetryInterval.Duration)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			d.flush(ctx)
+		}
+	}
+}
+
+// flush retries the queued messages that are due, sending outside of the lock
+// like Dispatch.
+func (d *Dispatcher) flush(ctx context.Context) {
+	type due struct {
+		queued
+		ch *channel
+	}
+
+	d.mu.Lock()
+	if len(d.queue) == 0 {
+		d.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	var pending []queued
+	var sends []due
+
+	for _, q := range d.queue {
+		ch, ok := d.channels[q.Channel]
+		if !ok || now.Sub(q.Message.Time) > d.cfg.RetryMaxAge.Duration {
+			slog.Warn("drop notification", "title", q.Message.Title, "channel", q.Channel)
+			continue
+		}
+
+		if now.Before(q.NotBefore) {
+			pending = append(pending, q)
+			continue
+		}
+
+		sends = append(sends, due{q, ch})
+	}
+
+	d.queue = pending
+	d.mu.Unlock()
+
+	var failed []queued
+	for _, s := range sends {
+		if err := s.ch.Notify(ctx, s.Message); err != nil {
+			slog.Warn("failed to notify", "channel", s.ch.name, "attempt", s.Attempts+1, "error", err)
+
+			// back off linearly with the number of attempts
+			s.Attempts++
+			s.NotBefore = time.Now().Add(time.Duration(s.Attempts) * d.cfg.RetryInterval.Duration)
+			failed = append(failed, s.queued)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.queue = append(d.queue, failed...)
+	d.save()
+}
+
+func (d *Dispatcher) duplicate(ch *channel, m *Message) bool {
+	key := ch.name + "/" + m.key()
+	if t, ok := d.sent[key]; ok && time.Since(t) < d.cfg.Dedup.Duration {
+		return true
+	}
+
+	d.sent[key] = time.Now()
+	return false
+}
+
+func (d *Dispatcher) expire() {
+	for key, t := range d.sent {
+		if time.Since(t) >= d.cfg.Dedup.Duration {
+			delete(d.sent, key)
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(ch *channel, m *Message, attempts int, notBefore time.Time) {
+	d.queue = append(d.queue, queued{ch.name, m, attempts, notBefore})
+}
+
+func (d *Dispatcher) save() {
+	if err := d.store.Save(stateName, d.queue); err != nil {
+		slog.Error("failed to save notification queue", "error", err)
+	}
+}
+
+func (ch *channel) routes(m *Message) bool {
+	if m.Severity.Level() < ch.minSeverity.Level() {
+		return false
+	}
+
+	return len(ch.types) == 0 || slices.Contains(ch.types, m.Type)
+}
@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+type recordingNotifier struct {
+	err  error
+	sent []*Message
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, m *Message) error {
+	if n.err != nil {
+		return n.err
+	}
+
+	n.sent = append(n.sent, m)
+	return nil
+}
+
+// quietNow returns quiet hours around the current time.
+func quietNow(t *testing.T) *quietHours {
+	now := time.Now()
+	q, err := newQuietHours(config.QuietHours{
+		From: now.Add(-time.Hour).Format("15:04"),
+		To:   now.Add(time.Hour).Format("15:04"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return q
+}
+
+func message(title string, severity event.Severity) *Message {
+	return &Message{Type: event.BalanceChanged, Severity: severity, Title: title, Time: time.Now()}
+}
+
+func TestDispatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		dedup      time.Duration
+		quiet      bool
+		err        error
+		messages   []*Message
+		wantSent   int
+		wantQueued int
+	}{
+		{
+			name:     "distinct messages",
+			dedup:    time.Hour,
+			messages: []*Message{message("a", event.SeverityInfo), message("b", event.SeverityInfo)},
+			wantSent: 2,
+		},
+		{
+			name:     "duplicates are dropped",
+			dedup:    time.Hour,
+			messages: []*Message{message("a", event.SeverityInfo), message("a", event.SeverityInfo)},
+			wantSent: 1,
+		},
+		{
+			name:     "duplicates after the dedup window",
+			messages: []*Message{message("a", event.SeverityInfo), message("a", event.SeverityInfo)},
+			wantSent: 2,
+		},
+		{
+			name:       "quiet hours queue",
+			dedup:      time.Hour,
+			quiet:      true,
+			messages:   []*Message{message("a", event.SeverityWarning)},
+			wantQueued: 1,
+		},
+		{
+			name:     "critical messages ignore quiet hours",
+			dedup:    time.Hour,
+			quiet:    true,
+			messages: []*Message{message("a", event.SeverityCritical)},
+			wantSent: 1,
+		},
+		{
+			name:       "failed deliveries are queued",
+			dedup:      time.Hour,
+			err:        errors.New("unreachable"),
+			messages:   []*Message{message("a", event.SeverityInfo)},
+			wantQueued: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := store.Open(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer st.Close()
+
+			n := &recordingNotifier{err: tt.err}
+			ch := &channel{Notifier: n, name: "test", minSeverity: event.SeverityInfo}
+			if tt.quiet {
+				ch.quiet = quietNow(t)
+			}
+
+			d := &Dispatcher{
+				cfg: &config.Notifications{
+					Dedup:         config.Duration{Duration: tt.dedup},
+					RetryInterval: config.Duration{Duration: time.Minute},
+				},
+				store:    st,
+				channels: map[string]*channel{ch.name: ch},
+				sent:     make(map[string]time.Time),
+			}
+
+			for _, m := range tt.messages {
+				d.Dispatch(context.Background(), m)
+			}
+
+			if len(n.sent) != tt.wantSent {
+				t.Errorf("got %d sent, want %d", len(n.sent), tt.wantSent)
+			}
+
+			var queue []queued
+			if err := st.Load(stateName, &queue); err != nil {
+				t.Fatal(err)
+			}
+			if len(queue) != tt.wantQueued {
+				t.Errorf("got %d queued, want %d", len(queue), tt.wantQueued)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+type quietHours struct {
+	from time.Duration
+	to   time.Duration
+}
+
+func newQuietHours(c config.QuietHours) (*quietHours, error) {
+	if len(c.From) == 0 && len(c.To) == 0 {
+		return nil, nil
+	}
+
+	from, err := time.Parse("15:04", c.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours start - %w", err)
+	}
+
+	to, err := time.Parse("15:04", c.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours end - %w", err)
+	}
+
+	return &quietHours{sinceMidnight(from), sinceMidnight(to)}, nil
+}
+
+// until returns the end of the quiet hours if t is within them. Ranges may
+// span midnight, e.g. 22:00 to 07:00.
+func (q *quietHours) until(t time.Time) (time.Time, bool) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	now := t.Sub(midnight)
+
+	switch {
+	case q.from <= q.to && now >= q.from && now < q.to:
+		return midnight.Add(q.to), true
+	case q.from > q.to && now >= q.from:
+		return midnight.AddDate(0, 0, 1).Add(q.to), true
+	case q.from > q.to && now < q.to:
+		return midnight.Add(q.to), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
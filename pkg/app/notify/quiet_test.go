@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestQuietHours(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		t        time.Time
+		want     time.Time
+		wantOk   bool
+	}{
+		{"before a daytime range", "12:00", "14:00", at(19, 11, 59), time.Time{}, false},
+		{"within a daytime range", "12:00", "14:00", at(19, 12, 0), at(19, 14, 0), true},
+		{"end of a daytime range", "12:00", "14:00", at(19, 14, 0), time.Time{}, false},
+		{"evening of a range over midnight", "22:00", "07:00", at(19, 23, 30), at(20, 7, 0), true},
+		{"morning of a range over midnight", "22:00", "07:00", at(19, 6, 59), at(19, 7, 0), true},
+		{"outside a range over midnight", "22:00", "07:00", at(19, 7, 0), time.Time{}, false},
+		{"empty range", "08:00", "08:00", at(19, 8, 0), time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := newQuietHours(config.QuietHours{From: tt.from, To: tt.to})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, ok := q.until(tt.t)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("got %v %v, want %v %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestNewQuietHours(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{name: "not configured", wantNil: true},
+		{name: "valid", from: "22:00", to: "07:00"},
+		{name: "missing end", from: "22:00", wantErr: true},
+		{name: "invalid start", from: "25:00", to: "07:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := newQuietHours(config.QuietHours{From: tt.from, To: tt.to})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (q == nil) != tt.wantNil {
+				t.Errorf("got %v, want nil %v", q, tt.wantNil)
+			}
+		})
+	}
+}
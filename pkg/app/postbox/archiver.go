@@ -0,0 +1,213 @@
+package postbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/kaedwen/trade/pkg/config"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+const indexFile = ".archive.json"
+
+var unsafeChars = regexp.MustCompile(`[^\pL\pN._-]+`)
+
+var categories = []struct {
+	keyword  string
+	category string
+}{
+	{"steuer", "tax"},
+	{"abrechnung", "trade"},
+	{"kauf", "trade"},
+	{"verkauf", "trade"},
+	{"dividende", "income"},
+	{"ertrag", "income"},
+	{"kontoauszug", "statement"},
+	{"finanzreport", "statement"},
+	{"depotauszug", "statement"},
+	{"kosten", "costs"},
+}
+
+// Archiver stores new postbox documents into a directory. Archived document
+// ids are kept in an index next to the files, so nothing is fetched twice.
+type Archiver struct {
+	postbox  Postbox
+	cfg      *config.Config
+	template *template.Template
+
+	mu    sync.Mutex
+	index map[string]string
+}
+
+type archiveName struct {
+	Id       string
+	Name     string
+	Date     string
+	Category string
+	Ext      string
+}
+
+func NewArchiver(cfg *config.Config, p Postbox) (*Archiver, error) {
+	tpl, err := template.New("document").Parse(cfg.Postbox.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document name template - %w", err)
+	}
+
+	a := &Archiver{
+		postbox:  p,
+		cfg:      cfg,
+		template: tpl,
+		index:    make(map[string]string),
+	}
+
+	if err := a.loadIndex(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Archive downloads all given documents which are not archived yet.
+func (a *Archiver) Archive(ctx context.Context, documents []model.Document) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, doc := range documents {
+		if _, ok := a.index[doc.DocumentId]; ok {
+			continue
+		}
+
+		if doc.Advertisement && !a.cfg.Postbox.Advertisements {
+			continue
+		}
+
+		name, err := a.archive(ctx, &doc)
+		if err != nil {
+			return fmt.Errorf("failed to archive document %s - %w", doc.DocumentId, err)
+		}
+
+		slog.Info("archived document", "document", doc.DocumentId, "file", name)
+
+		a.index[doc.DocumentId] = name
+		if err := a.saveIndex(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *Archiver) archive(ctx context.Context, doc *model.Document) (string, error) {
+	name, err := a.fileName(doc)
+	if err != nil {
+		return "", err
+	}
+
+	p := filepath.Join(a.cfg.Postbox.Directory, name)
+	if _, err := os.Stat(p); err == nil {
+		ext := filepath.Ext(name)
+		name = strings.TrimSuffix(name, ext) + "_" + doc.DocumentId + ext
+		p = filepath.Join(a.cfg.Postbox.Directory, name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(p), ".download-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if err := a.postbox.Download(ctx, doc, f); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	return name, os.Rename(f.Name(), p)
+}
+
+func (a *Archiver) fileName(doc *model.Document) (string, error) {
+	ext := ".pdf"
+	if exts, _ := mime.ExtensionsByType(doc.MimeType); len(exts) > 0 {
+		ext = exts[0]
+	}
+
+	var buf bytes.Buffer
+	if err := a.template.Execute(&buf, archiveName{
+		Id:       doc.DocumentId,
+		Name:     sanitize(doc.Name),
+		Date:     doc.DateCreation,
+		Category: category(doc.Name),
+		Ext:      ext,
+	}); err != nil {
+		return "", err
+	}
+
+	name := filepath.Clean(buf.String())
+	if !filepath.IsLocal(name) {
+		return "", errors.New("document name leaves the archive directory")
+	}
+
+	if len(filepath.Ext(name)) == 0 {
+		name += ext
+	}
+
+	return name, nil
+}
+
+func (a *Archiver) loadIndex() error {
+	data, err := os.ReadFile(filepath.Join(a.cfg.Postbox.Directory, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &a.index)
+}
+
+func (a *Archiver) saveIndex() error {
+	data, err := json.MarshalIndent(a.index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	p := filepath.Join(a.cfg.Postbox.Directory, indexFile)
+	if err := os.WriteFile(p+".tmp", data, 0o640); err != nil {
+		return err
+	}
+
+	return os.Rename(p+".tmp", p)
+}
+
+func category(name string) string {
+	name = strings.ToLower(name)
+	for _, c := range categories {
+		if strings.Contains(name, c.keyword) {
+			return c.category
+		}
+	}
+
+	return "other"
+}
+
+func sanitize(s string) string {
+	return strings.Trim(unsafeChars.ReplaceAllString(s, "_"), "_")
+}
@@ -0,0 +1,107 @@
+package postbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/kaedwen/trade/pkg/app/client"
+	"github.com/kaedwen/trade/pkg/app/session"
+	"github.com/kaedwen/trade/pkg/config"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+const (
+	ApiDocumentsPath = "/messages/clients/user/v2/documents"
+	ApiDocumentPath  = "/messages/v2/documents/%s"
+)
+
+const pageSize = 100
+
+type Postbox interface {
+	Documents(context.Context) ([]model.Document, error)
+	Download(context.Context, *model.Document, io.Writer) error
+}
+
+type postbox struct {
+	session.Session
+	cfg *config.Config
+}
+
+func NewPostbox(cfg *config.Config, s session.Session) Postbox {
+	return &postbox{s, cfg}
+}
+
+// Documents lists all documents of the postbox, following the paging.
+func (p *postbox) Documents(ctx context.Context) ([]model.Document, error) {
+	var documents []model.Document
+
+	for {
+		u := p.cfg.ApiAddress.JoinPath(ApiDocumentsPath)
+		u.RawQuery = url.Values{
+			"paging-first": {strconv.Itoa(len(documents))},
+			"paging-count": {strconv.Itoa(pageSize)},
+		}.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add("x-http-request-info", p.NewRequestInfo())
+
+		resp, err := client.FromContext(ctx).Do(req, client.WithEndpoint(ApiDocumentsPath))
+		if err != nil {
+			return nil, err
+		}
+
+		var data model.DocumentsResponse
+		err = decode(resp, &data)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, data.Values...)
+		if len(data.Values) == 0 || len(documents) >= data.Paging.Matches {
+			return documents, nil
+		}
+	}
+}
+
+// Download writes the content of the document to w.
+func (p *postbox) Download(ctx context.Context, doc *model.Document, w io.Writer) error {
+	slog.Info("download document", "document", doc.DocumentId)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.ApiAddress.JoinPath(fmt.Sprintf(ApiDocumentPath, doc.DocumentId)).String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("x-http-request-info", p.NewRequestInfo())
+	req.Header.Set("Accept", doc.MimeType)
+
+	resp, err := client.FromContext(ctx).Do(req, client.WithEndpoint(ApiDocumentPath))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return client.BadStatus(resp)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func decode(resp *http.Response, out any) error {
+	if resp.StatusCode != http.StatusOK {
+		return client.BadStatus(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
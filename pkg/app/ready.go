@@ -0,0 +1,61 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/server"
+)
+
+// jobAgeIntervals is the number of job intervals allowed since the last
+// success when no maximum age is configured.
+const jobAgeIntervals = 3
+
+// Checks reports whether the session is active, the token is not about to
+// expire and every job succeeded recently.
+func (a *Application) Checks() []server.Check {
+	checks := []server.Check{{Name: "session", Ok: a.Active(), Message: string(a.State())}}
+
+	margin := a.cfg.Http.Ready.TokenMargin.Duration
+	token := server.Check{Name: "token", Message: "no token"}
+	if c, _ := a.loggedIn(); c != nil {
+		if expiry := c.Expiry(); !expiry.IsZero() {
+			token.Ok = time.Until(expiry) > margin
+			token.Message = fmt.Sprintf("expires in %v", time.Until(expiry).Round(time.Second))
+		}
+	}
+	checks = append(checks, token)
+
+	if a.scheduler == nil {
+		return checks
+	}
+
+	for _, j := range a.scheduler.Status() {
+		maxAge := a.cfg.Http.Ready.MaxJobAge.Duration
+		if maxAge == 0 {
+			maxAge = jobAgeIntervals * j.Interval
+		}
+
+		c := server.Check{Name: "job:" + j.Name}
+		switch {
+		case j.Paused:
+			c.Ok, c.Message = true, "paused"
+		case j.LastSuccess.IsZero():
+			// a new session gets the time to fetch first
+			since := a.activeSince.Load()
+			c.Ok = since != nil && time.Since(*since) < maxAge
+			c.Message = "no success yet"
+		default:
+			age := time.Since(j.LastSuccess)
+			c.Ok = age < maxAge
+			c.Message = fmt.Sprintf("last success %v ago", age.Round(time.Second))
+		}
+		if len(j.LastError) > 0 && !c.Ok {
+			c.Message += " - " + j.LastError
+		}
+
+		checks = append(checks, c)
+	}
+
+	return checks
+}
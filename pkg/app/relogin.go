@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/client"
+	"github.com/kaedwen/trade/pkg/app/lockout"
+	"github.com/kaedwen/trade/pkg/app/scheduler"
+	"github.com/kaedwen/trade/pkg/app/session"
+	"github.com/kaedwen/trade/pkg/app/systemd"
+)
+
+const (
+	reloginBackoff    = time.Minute
+	reloginMaxBackoff = 30 * time.Minute
+)
+
+// guard marks the session expired when a job fails because the API no longer
+// accepts the token or session.
+func (a *Application) guard(fn scheduler.JobFunc) scheduler.JobFunc {
+	return func(ctx context.Context) error {
+		return a.expireOn(fn(ctx))
+	}
+}
+
+// expireOn marks the session expired when err shows that the API no longer
+// accepts the token or session. It returns err unchanged.
+func (a *Application) expireOn(err error) error {
+	if errors.Is(err, client.ErrUnauthorized) && a.Expire() {
+		slog.Warn("session expired", "error", err)
+	}
+
+	return err
+}
+
+// relogin logs in until the session is active, backing off exponentially
+// between failed attempts, retryLogin cuts the wait short. A locked login
+// waits for retryLogin only. Each attempt asks for a new TAN, which is
+// announced like any other.
+func (a *Application) relogin(ctx context.Context) {
+	if !a.relogging.CompareAndSwap(false, true) {
+		return
+	}
+	defer a.relogging.Store(false)
+
+	backoff := reloginBackoff
+
+	// a retry requested before this loop started is covered by its first attempt
+	select {
+	case <-a.retry:
+	default:
+	}
+
+	for a.State() != session.StateActive {
+		err := a.Reauth(ctx)
+		switch {
+		case err == nil:
+			return
+		case errors.Is(err, ErrReauthRunning):
+			// a manual re-authentication is running, check its outcome later
+		case errors.Is(err, lockout.ErrLocked):
+			// only unlocking the login helps, which asks for a retry
+			slog.Error("login failed", "error", err)
+			systemd.Notify(systemd.Status("password login locked"))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.retry:
+				backoff = reloginBackoff
+				continue
+			}
+		default:
+			slog.Error("login failed", "retry", backoff, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.retry:
+			// e.g. the login was unlocked, try right away
+			backoff = reloginBackoff
+			continue
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, reloginMaxBackoff)
+	}
+}
+
+// retryLogin makes a running relogin loop try again right away with its
+// backoff reset, or starts one.
+func (a *Application) retryLogin(ctx context.Context) {
+	select {
+	case a.retry <- struct{}{}:
+	default:
+	}
+
+	go a.relogin(ctx)
+}
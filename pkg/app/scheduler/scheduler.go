@@ -0,0 +1,214 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/metrics"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobPaused  = errors.New("job is paused")
+	ErrHeld       = errors.New("jobs are held")
+)
+
+type JobFunc func(context.Context) error
+
+type Scheduler interface {
+	Add(string, time.Duration, JobFunc)
+	Run(context.Context)
+	Status() []JobStatus
+	Trigger(string) error
+	Pause(string) error
+	Resume(string) error
+	Hold(bool)
+}
+
+type JobStatus struct {
+	Name        string        `json:"name"`
+	Interval    time.Duration `json:"interval"`
+	Paused      bool          `json:"paused"`
+	Held        bool          `json:"held"`
+	LastRun     time.Time     `json:"lastRun,omitempty"`
+	LastSuccess time.Time     `json:"lastSuccess,omitempty"`
+	LastError   string        `json:"lastError,omitempty"`
+}
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       JobFunc
+	trigger  chan struct{}
+
+	mu     sync.Mutex
+	status JobStatus
+}
+
+type scheduler struct {
+	jobs []*job
+	held atomic.Bool
+}
+
+func NewScheduler() Scheduler {
+	return &scheduler{}
+}
+
+// Add registers a job running every interval. Jobs must be added before Run.
+func (s *scheduler) Add(name string, interval time.Duration, fn JobFunc) {
+	s.jobs = append(s.jobs, &job{
+		name:     name,
+		interval: interval,
+		fn:       fn,
+		trigger:  make(chan struct{}, 1),
+		status:   JobStatus{Name: name, Interval: interval},
+	})
+}
+
+// Status reports the last run of every job.
+func (s *scheduler) Status() []JobStatus {
+	status := make([]JobStatus, len(s.jobs))
+	for i, j := range s.jobs {
+		j.mu.Lock()
+		status[i] = j.status
+		j.mu.Unlock()
+		status[i].Held = s.held.Load()
+	}
+
+	return status
+}
+
+// Trigger runs the job as soon as possible, outside of its interval.
+func (s *scheduler) Trigger(name string) error {
+	j, err := s.job(name)
+	if err != nil {
+		return err
+	}
+
+	if j.paused() {
+		return ErrJobPaused
+	}
+
+	if s.held.Load() {
+		return ErrHeld
+	}
+
+	select {
+	case j.trigger <- struct{}{}:
+	default:
+		// a run is already pending
+	}
+
+	return nil
+}
+
+// Pause skips the runs of the job until it is resumed.
+func (s *scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+func (s *scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+// Hold skips the runs of all jobs, e.g. while there is no active session.
+// Jobs run right after they are released.
+func (s *scheduler) Hold(held bool) {
+	if s.held.Swap(held) && !held {
+		for _, j := range s.jobs {
+			select {
+			case j.trigger <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (s *scheduler) setPaused(name string, paused bool) error {
+	j, err := s.job(name)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.Paused = paused
+	return nil
+}
+
+func (s *scheduler) job(name string) (*job, error) {
+	for _, j := range s.jobs {
+		if j.name == name {
+			return j, nil
+		}
+	}
+
+	return nil, ErrUnknownJob
+}
+
+// Run starts all jobs and blocks until the context is done.
+func (s *scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			j.loop(ctx, &s.held)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (j *job) loop(ctx context.Context, held *atomic.Bool) {
+	t := time.NewTicker(j.interval)
+	defer t.Stop()
+
+	for {
+		if !j.paused() && !held.Load() {
+			j.run(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-j.trigger:
+		}
+	}
+}
+
+func (j *job) paused() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.status.Paused
+}
+
+func (j *job) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, j.interval)
+	defer cancel()
+
+	start := time.Now()
+	err := j.fn(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.LastRun = start
+	if err != nil {
+		slog.Warn("job failed", "job", j.name, "error", err)
+		j.status.LastError = err.Error()
+		return
+	}
+
+	j.status.LastSuccess = start
+	j.status.LastError = ""
+	metrics.JobLastSuccess.With(j.name).SetTime(start)
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+var (
+	ErrNotFetched    = errors.New("nothing fetched yet")
+	ErrNoStore       = errors.New("no store available")
+	ErrUnknownSeries = errors.New("unknown series")
+)
+
+// cached wraps data served from the cache with the time it was fetched.
+type cached struct {
+	FetchedAt time.Time `json:"fetchedAt"`
+	Data      any       `json:"data"`
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+func (s *server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	s.serveSnapshot(w, func(snapshot *event.Snapshot) any {
+		accounts := []model.ReportBalance{}
+		for _, v := range snapshot.Balances.Values {
+			if v.ProductType != model.ProductTypeDepot {
+				accounts = append(accounts, v)
+			}
+		}
+
+		return accounts
+	})
+}
+
+func (s *server) handleDepots(w http.ResponseWriter, r *http.Request) {
+	s.serveSnapshot(w, func(snapshot *event.Snapshot) any {
+		depots := []model.ReportBalance{}
+		for _, v := range snapshot.Balances.Values {
+			if v.ProductType == model.ProductTypeDepot {
+				depots = append(depots, v)
+			}
+		}
+
+		return depots
+	})
+}
+
+func (s *server) handlePositions(w http.ResponseWriter, r *http.Request) {
+	depotId := r.URL.Query().Get("depot")
+
+	s.serveSnapshot(w, func(snapshot *event.Snapshot) any {
+		positions := []model.Position{}
+		for _, p := range snapshot.Positions {
+			if len(depotId) == 0 || p.DepotId == depotId {
+				positions = append(positions, p)
+			}
+		}
+
+		return positions
+	})
+}
+
+func (s *server) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	accountId := r.URL.Query().Get("account")
+
+	s.serveSnapshot(w, func(snapshot *event.Snapshot) any {
+		if len(accountId) == 0 {
+			return snapshot.Transactions
+		}
+
+		return map[string][]model.Transaction{accountId: snapshot.Transactions[accountId]}
+	})
+}
+
+func (s *server) handleDocuments(w http.ResponseWriter, r *http.Request) {
+	documents, t := s.cache.Documents()
+	if t.IsZero() {
+		writeError(w, http.StatusServiceUnavailable, ErrNotFetched)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cached{t, documents})
+}
+
+func (s *server) serveSnapshot(w http.ResponseWriter, data func(*event.Snapshot) any) {
+	snapshot := s.cache.Snapshot()
+	if snapshot == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrNotFetched)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cached{snapshot.Time, data(snapshot)})
+}
+
+func (s *server) handleNetWorth(w http.ResponseWriter, r *http.Request) {
+	snapshot := s.cache.Snapshot()
+	if snapshot == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrNotFetched)
+		return
+	}
+
+	nw, err := snapshot.Balances.NetWorth()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cached{snapshot.Time, nw})
+}
+
+// handleHistory queries the snapshot store, e.g.
+// /v1/history?series=depots&key=*&from=2024-01-01T00:00:00Z&resolution=24h
+func (s *server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if s.store == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrNoStore)
+		return
+	}
+
+	v := r.URL.Query()
+	q := store.Query{Series: store.Series(v.Get("series")), Key: v.Get("key")}
+
+	var err error
+	if len(v.Get("from")) > 0 {
+		if q.From, err = time.Parse(time.RFC3339, v.Get("from")); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if len(v.Get("to")) > 0 {
+		if q.To, err = time.Parse(time.RFC3339, v.Get("to")); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if len(v.Get("resolution")) > 0 {
+		if q.Resolution, err = time.ParseDuration(v.Get("resolution")); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	switch q.Series {
+	case store.SeriesBalances, store.SeriesDepots, store.SeriesPositions:
+	default:
+		writeError(w, http.StatusBadRequest, ErrUnknownSeries)
+		return
+	}
+
+	samples, err := s.store.Query(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if samples == nil {
+		samples = []store.Sample{}
+	}
+
+	writeJSON(w, http.StatusOK, samples)
+}
+
+func (s *server) handleReauth(w http.ResponseWriter, r *http.Request) {
+	if err := s.reauth(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+)
+
+var ErrNoStream = errors.New("streaming not supported")
+
+const heartbeat = 30 * time.Second
+
+// handleEvents streams change events as server-sent events. Clients resume
+// with the Last-Event-ID header after a reconnect.
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, ErrNoStream)
+		return
+	}
+
+	requested, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+
+	var types []event.Type
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if len(t) > 0 {
+			types = append(types, event.Type(t))
+		}
+	}
+
+	lastId, backlog, ch, cancel := s.events.Listen(requested)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(e event.Event) error {
+		if e.Id <= lastId || (len(types) > 0 && !slices.Contains(types, e.Type)) {
+			return nil
+		}
+		lastId = e.Id
+
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Id, e.Type, data)
+		return err
+	}
+
+	for _, e := range backlog {
+		if err := send(e); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	t := time.NewTicker(heartbeat)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-t.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := send(e); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
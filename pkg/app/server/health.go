@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+)
+
+// Check is the outcome of a single readiness check.
+type Check struct {
+	Name    string `json:"name"`
+	Ok      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+type health struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks,omitempty"`
+}
+
+// handleHealth reports that the process is alive and serving.
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, health{Status: "ok"})
+}
+
+// handleReady reports whether all checks pass, 503 otherwise.
+func (s *server) handleReady(w http.ResponseWriter, r *http.Request) {
+	h := health{Status: "ok", Checks: s.checks()}
+
+	status := http.StatusOK
+	for _, c := range h.Checks {
+		if !c.Ok {
+			h.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	writeJSON(w, status, h)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/brokerage"
+	"github.com/kaedwen/trade/pkg/app/tan"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+// confirmTimeout is the time an order waits for its TAN to be answered with
+// `trade ctl approve` or `trade ctl tan`, it fails afterwards.
+const confirmTimeout = 5 * time.Minute
+
+func (s *server) handleOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	orders, err := s.brokerage.Orders(r.Context(), r.PathValue("depotId"), brokerage.OrderFilter{
+		Status:  model.OrderStatus(q.Get("state")),
+		Side:    model.OrderSide(q.Get("side")),
+		Type:    model.OrderType(q.Get("type")),
+		VenueId: q.Get("venue"),
+	})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+func (s *server) handleOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := s.brokerage.Order(r.Context(), r.PathValue("orderId"))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+func (s *server) handlePlaceOrder(w http.ResponseWriter, r *http.Request) {
+	var order model.OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	order.DepotId = r.PathValue("depotId")
+
+	ctx, cancel := context.WithTimeout(r.Context(), confirmTimeout)
+	defer cancel()
+
+	placed, err := s.brokerage.PlaceOrder(ctx, order, s.approver)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, placed)
+}
+
+func (s *server) handleChangeOrder(w http.ResponseWriter, r *http.Request) {
+	var change model.OrderChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	change.OrderId = r.PathValue("orderId")
+
+	ctx, cancel := context.WithTimeout(r.Context(), confirmTimeout)
+	defer cancel()
+
+	order, err := s.brokerage.ChangeOrder(ctx, change, s.approver)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+func (s *server) handleCancelOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), confirmTimeout)
+	defer cancel()
+
+	order, err := s.brokerage.CancelOrder(ctx, r.PathValue("orderId"), s.approver)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// writeOrderError maps errors of order changes, including an unanswered or
+// busy TAN challenge.
+func writeOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, brokerage.ErrOrderNotAllowed):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case errors.Is(err, tan.ErrBusy):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, fmt.Errorf("TAN not answered in time - %w", err))
+	default:
+		writeError(w, http.StatusBadGateway, err)
+	}
+}
+
+func (s *server) handleDimensions(w http.ResponseWriter, r *http.Request) {
+	d, err := s.brokerage.Dimensions(r.Context(), r.PathValue("instrumentId"))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, d)
+}
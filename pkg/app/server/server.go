@@ -0,0 +1,287 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/brokerage"
+	"github.com/kaedwen/trade/pkg/app/cache"
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/metrics"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/app/tan"
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotSupported = errors.New("not supported")
+	ErrReadOnly     = errors.New("write endpoints are disabled (http.write)")
+	ErrInsecure     = errors.New("refusing to serve a non-loopback address without http.token")
+)
+
+type Server interface {
+	Serve(context.Context) error
+}
+
+type server struct {
+	cfg       *config.Config
+	brokerage brokerage.Brokerage
+	approver  tan.Approver
+	cache     *cache.Cache
+	status    func() any
+	events    *event.Ring
+	store     store.Store
+	reauth    func() error
+	checks    func() []Check
+	mux       *http.ServeMux
+}
+
+type Option func(*server)
+
+// WithCache serves the latest fetched data on the read-only endpoints.
+func WithCache(c *cache.Cache) Option {
+	return func(s *server) {
+		s.cache = c
+	}
+}
+
+// WithEvents streams the events recorded by the ring on /v1/events.
+func WithEvents(r *event.Ring) Option {
+	return func(s *server) {
+		s.events = r
+	}
+}
+
+// WithStore serves the snapshot history on /v1/history.
+func WithStore(st store.Store) Option {
+	return func(s *server) {
+		s.store = st
+	}
+}
+
+// WithReauth lets clients trigger a new login, fn must not block on the TAN
+// approval.
+func WithReauth(fn func() error) Option {
+	return func(s *server) {
+		s.reauth = fn
+	}
+}
+
+// WithChecks serves the result of fn on /readyz.
+func WithChecks(fn func() []Check) Option {
+	return func(s *server) {
+		s.checks = fn
+	}
+}
+
+// WithStatus serves the result of fn on /v1/status.
+func WithStatus(fn func() any) Option {
+	return func(s *server) {
+		s.status = fn
+	}
+}
+
+func NewServer(cfg *config.Config, b brokerage.Brokerage, approver tan.Approver, opt ...Option) Server {
+	s := &server{
+		cfg:       cfg,
+		brokerage: b,
+		approver:  approver,
+		cache:     cache.NewCache(nil),
+		status:    func() any { return struct{}{} },
+		events:    event.NewRing(0),
+		reauth:    func() error { return ErrNotSupported },
+		checks:    func() []Check { return nil },
+		mux:       http.NewServeMux(),
+	}
+
+	for _, o := range opt {
+		o(s)
+	}
+
+	s.mux.HandleFunc("GET /v1/status", s.handleStatus)
+	s.mux.HandleFunc("GET /v1/accounts", s.handleAccounts)
+	s.mux.HandleFunc("GET /v1/depots", s.handleDepots)
+	s.mux.HandleFunc("GET /v1/positions", s.handlePositions)
+	s.mux.HandleFunc("GET /v1/transactions", s.handleTransactions)
+	s.mux.HandleFunc("GET /v1/documents", s.handleDocuments)
+	s.mux.HandleFunc("GET /v1/events", s.handleEvents)
+	s.mux.HandleFunc("GET /v1/networth", s.handleNetWorth)
+	s.mux.HandleFunc("GET /v1/history", s.handleHistory)
+	s.mux.HandleFunc("GET /v1/depots/{depotId}/orders", s.handleOrders)
+	s.mux.HandleFunc("GET /v1/orders/{orderId}", s.handleOrder)
+	s.mux.HandleFunc("GET /v1/instruments/{instrumentId}/dimensions", s.handleDimensions)
+
+	// endpoints changing the session or orders are only served on opt-in
+	s.mux.HandleFunc("POST /v1/session/reauth", s.writable(s.handleReauth))
+	s.mux.HandleFunc("POST /v1/depots/{depotId}/orders", s.writable(s.handlePlaceOrder))
+	s.mux.HandleFunc("PATCH /v1/orders/{orderId}", s.writable(s.handleChangeOrder))
+	s.mux.HandleFunc("DELETE /v1/orders/{orderId}", s.writable(s.handleCancelOrder))
+
+	if cfg.Http.Metrics {
+		s.mux.Handle("GET /metrics", metrics.Default)
+	}
+
+	return s
+}
+
+// Serve listens on the configured address until the context is done. Request
+// contexts derive from ctx, so they carry the authenticated client.
+func (s *server) Serve(ctx context.Context) error {
+	if len(s.cfg.Http.Address) == 0 {
+		return nil
+	}
+
+	if err := CheckConfig(s.cfg); err != nil {
+		return err
+	}
+
+	l, err := listen(s.cfg.Http.Address)
+	if err != nil {
+		return err
+	}
+
+	// the dashboard assets hold no data and are served without token, the
+	// dashboard asks for it and sends it along with its API requests
+	root := http.NewServeMux()
+	root.Handle("/v1/", s.authenticate(s.mux))
+	root.Handle("/metrics", s.authenticate(s.mux))
+	// probes of orchestrators and uptime checkers usually carry no token
+	root.HandleFunc("GET /healthz", s.handleHealth)
+	root.HandleFunc("GET /readyz", s.handleReady)
+	root.Handle("/", dashboard())
+
+	srv := &http.Server{
+		Handler:     root,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		srv.Shutdown(sctx)
+	}()
+
+	slog.Info("http server listening", "address", l.Addr())
+
+	if len(s.cfg.Http.TLS.Cert) > 0 {
+		err = srv.ServeTLS(l, s.cfg.Http.TLS.Cert, s.cfg.Http.TLS.Key)
+	} else {
+		err = srv.Serve(l)
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// listen opens a TCP listener or, for addresses prefixed with "unix:", a Unix
+// socket accessible only by the owner and group.
+func listen(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, "unix:")
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket - %w", err)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, 0660); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// CheckConfig refuses configurations exposing the API to other hosts without
+// authentication.
+func CheckConfig(cfg *config.Config) error {
+	if len(cfg.Http.Address) > 0 && len(cfg.Http.Token) == 0 && !local(cfg.Http.Address) {
+		return ErrInsecure
+	}
+
+	return nil
+}
+
+// local reports whether the address is a Unix socket or a loopback address,
+// which are not reachable from other hosts.
+func local(address string) bool {
+	if strings.HasPrefix(address, "unix:") {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// writable refuses requests unless write endpoints are enabled.
+func (s *server) writable(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.cfg.Http.Write {
+			writeError(w, http.StatusForbidden, ErrReadOnly)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// authenticate requires the configured bearer token on every request.
+func (s *server) authenticate(next http.Handler) http.Handler {
+	if len(s.cfg.Http.Token) == 0 {
+		return next
+	}
+
+	want := []byte("Bearer " + s.cfg.Http.Token)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
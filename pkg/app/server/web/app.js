@@ -0,0 +1,296 @@
+"use strict";
+
+// The dashboard only talks to the local API. When the API requires a bearer
+// token it is asked for once and kept in the local storage.
+
+const colors = ["#2f5fd0", "#1a7f37", "#c62828", "#8a5a00", "#7b3fb5", "#00838f"];
+
+function token() {
+  return localStorage.getItem("trade-token") || "";
+}
+
+async function api(path, options = {}) {
+  const headers = {};
+  if (token()) {
+    headers["Authorization"] = "Bearer " + token();
+  }
+
+  const resp = await fetch(path, { ...options, headers });
+  if (resp.status === 401) {
+    const t = prompt("API token");
+    if (t) {
+      localStorage.setItem("trade-token", t);
+      return api(path, options);
+    }
+  }
+  if (resp.status === 202 || resp.status === 204) {
+    return null;
+  }
+
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+
+  return body;
+}
+
+function num(v) {
+  return parseFloat(v || 0);
+}
+
+function money(amount, unit) {
+  return num(amount).toLocaleString(undefined, { minimumFractionDigits: 2, maximumFractionDigits: 2 }) + (unit ? " " + unit : "");
+}
+
+function signed(el, v) {
+  el.classList.add(v < 0 ? "neg" : "pos");
+  return el;
+}
+
+function cell(tr, text, cls) {
+  const td = document.createElement("td");
+  td.textContent = text;
+  if (cls) {
+    td.className = cls;
+  }
+  tr.appendChild(td);
+  return td;
+}
+
+function rows(selector, items, fill) {
+  const tbody = document.querySelector(selector + " tbody");
+  tbody.replaceChildren();
+  for (const item of items) {
+    const tr = document.createElement("tr");
+    fill(tr, item);
+    tbody.appendChild(tr);
+  }
+}
+
+function ago(time) {
+  if (!time || time.startsWith("0001")) {
+    return "never";
+  }
+  const s = Math.round((Date.now() - new Date(time)) / 1000);
+  if (s < 120) return s + "s ago";
+  if (s < 7200) return Math.round(s / 60) + "m ago";
+  return Math.round(s / 3600) + "h ago";
+}
+
+async function loadStatus() {
+  const status = await api("/v1/status");
+  const state = document.getElementById("session-state");
+  const tan = document.getElementById("session-tan");
+  const session = status.session || {};
+
+  if (session.pendingTan) {
+    state.textContent = "waiting for TAN";
+    state.className = "badge pending";
+    tan.textContent = session.pendingTan.type + " challenge " + session.pendingTan.id;
+    if (session.pendingTan.type === "P_TAN" && session.pendingTan.challenge) {
+      const img = document.createElement("img");
+      img.className = "phototan";
+      img.alt = "photoTAN";
+      img.src = "data:image/png;base64," + session.pendingTan.challenge;
+      tan.appendChild(img);
+    } else if (session.pendingTan.challenge) {
+      tan.textContent += " (" + session.pendingTan.challenge + ")";
+    }
+  } else if (session.active) {
+    state.textContent = session.reused ? "active (reused)" : "active";
+    state.className = "badge active";
+    tan.textContent = status.tokenExpiry ? "token expires " + new Date(status.tokenExpiry).toLocaleTimeString() : "";
+  } else {
+    state.textContent = session.state || "inactive";
+    state.className = session.state === "Authenticating" ? "badge pending" : "badge inactive";
+    tan.textContent = "";
+  }
+
+  document.getElementById("reauth").disabled = session.reauth || !!session.pendingTan;
+
+  rows("#jobs", status.jobs || [], (tr, j) => {
+    cell(tr, j.name);
+    cell(tr, ago(j.lastSuccess));
+    cell(tr, j.lastError || "");
+  });
+}
+
+async function loadNetWorth() {
+  const nw = await api("/v1/networth");
+  document.getElementById("networth-total").textContent = money(nw.data.total, nw.data.unit);
+  document.getElementById("fetched").textContent = "fetched " + ago(nw.fetchedAt);
+
+  const table = document.getElementById("networth-groups");
+  table.replaceChildren();
+  for (const g of nw.data.groups || []) {
+    const tr = document.createElement("tr");
+    cell(tr, g.text || g.productType);
+    cell(tr, g.count);
+    signed(cell(tr, money(g.value, nw.data.unit), "num"), g.value);
+    table.appendChild(tr);
+  }
+}
+
+async function loadAccounts() {
+  const accounts = await api("/v1/accounts");
+  rows("#accounts", accounts.data, (tr, a) => {
+    const b = a.balance || {};
+    const account = b.account || {};
+    cell(tr, account.accountDisplayId || b.accountId || b.cardId || a.productId);
+    cell(tr, (account.accountType && account.accountType.text) || a.productType);
+    signed(cell(tr, money(b.balance && b.balance.value, b.balance && b.balance.unit), "num"), num(b.balance && b.balance.value));
+    const cash = b.availableCashAmount || {};
+    cell(tr, money(cash.value, cash.unit), "num");
+  });
+}
+
+async function loadPositions() {
+  const positions = await api("/v1/positions");
+  rows("#positions", positions.data, (tr, p) => {
+    const name = (p.instrument && (p.instrument.shortName || p.instrument.name)) || p.instrumentId;
+    cell(tr, name);
+    cell(tr, p.wkn);
+    cell(tr, num(p.quantity.value).toLocaleString(), "num");
+    cell(tr, money(p.currentPrice.price.value, p.currentPrice.price.unit), "num");
+    cell(tr, money(p.currentValue.value, p.currentValue.unit), "num");
+    signed(cell(tr, money(p.profitLossPurchaseAbs.value, p.profitLossPurchaseAbs.unit), "num"), num(p.profitLossPurchaseAbs.value));
+    signed(cell(tr, num(p.profitLossPurchaseRel).toFixed(2) + " %", "num"), num(p.profitLossPurchaseRel));
+  });
+}
+
+async function loadTransactions() {
+  const transactions = await api("/v1/transactions");
+  const all = [];
+  for (const [account, list] of Object.entries(transactions.data || {})) {
+    for (const t of list || []) {
+      all.push({ account, ...t });
+    }
+  }
+  all.sort((a, b) => (b.bookingDate || "").localeCompare(a.bookingDate || ""));
+
+  rows("#transactions", all.slice(0, 25), (tr, t) => {
+    const party = t.remitter || t.creditor || t.deptor || {};
+    cell(tr, t.bookingDate);
+    cell(tr, t.account);
+    cell(tr, party.holderName || t.remittanceInfo || "");
+    cell(tr, t.transactionType.text);
+    signed(cell(tr, money(t.amount.value, t.amount.unit), "num"), num(t.amount.value));
+  });
+}
+
+const valueOf = {
+  depots: (v) => v.currentValue,
+  balances: (v) => (v.balanceEUR !== undefined ? v.balanceEUR : v.balance),
+};
+
+async function loadHistory() {
+  const series = document.getElementById("history-series").value;
+  const days = parseInt(document.getElementById("history-range").value, 10);
+  const from = new Date(Date.now() - days * 86400000).toISOString().replace(/\.\d+Z$/, "Z");
+  const resolution = days > 60 ? "24h" : days > 10 ? "6h" : "1h";
+
+  const samples = await api(`/v1/history?series=${series}&from=${from}&resolution=${resolution}`);
+
+  const lines = new Map();
+  for (const s of samples) {
+    if (!lines.has(s.k)) {
+      lines.set(s.k, []);
+    }
+    lines.get(s.k).push([new Date(s.t).getTime(), valueOf[series](s.v) || 0]);
+  }
+
+  drawChart(lines);
+}
+
+function drawChart(lines) {
+  const svg = document.getElementById("chart");
+  const legend = document.getElementById("legend");
+  const ns = "http://www.w3.org/2000/svg";
+  const w = 800, h = 260, pad = 40;
+
+  svg.replaceChildren();
+  legend.replaceChildren();
+
+  const points = [...lines.values()].flat();
+  if (points.length === 0) {
+    const text = document.createElementNS(ns, "text");
+    text.setAttribute("x", w / 2);
+    text.setAttribute("y", h / 2);
+    text.setAttribute("text-anchor", "middle");
+    text.textContent = "no data";
+    svg.appendChild(text);
+    return;
+  }
+
+  const xs = points.map((p) => p[0]);
+  const ys = points.map((p) => p[1]);
+  const x0 = Math.min(...xs), x1 = Math.max(...xs) || x0 + 1;
+  let y0 = Math.min(...ys), y1 = Math.max(...ys);
+  if (y0 === y1) {
+    y0 -= 1;
+    y1 += 1;
+  }
+
+  const x = (v) => pad + ((v - x0) / (x1 - x0 || 1)) * (w - 2 * pad);
+  const y = (v) => h - pad / 2 - ((v - y0) / (y1 - y0)) * (h - pad);
+
+  for (let i = 0; i <= 4; i++) {
+    const v = y0 + ((y1 - y0) * i) / 4;
+    const line = document.createElementNS(ns, "line");
+    line.setAttribute("class", "grid");
+    line.setAttribute("x1", pad);
+    line.setAttribute("x2", w - pad);
+    line.setAttribute("y1", y(v));
+    line.setAttribute("y2", y(v));
+    svg.appendChild(line);
+
+    const label = document.createElementNS(ns, "text");
+    label.setAttribute("x", 2);
+    label.setAttribute("y", y(v) + 4);
+    label.textContent = Math.round(v).toLocaleString();
+    svg.appendChild(label);
+  }
+
+  let i = 0;
+  for (const [key, values] of lines) {
+    const color = colors[i++ % colors.length];
+    const path = document.createElementNS(ns, "polyline");
+    path.setAttribute("fill", "none");
+    path.setAttribute("stroke", color);
+    path.setAttribute("stroke-width", "2");
+    path.setAttribute("vector-effect", "non-scaling-stroke");
+    path.setAttribute("points", values.map((p) => x(p[0]) + "," + y(p[1])).join(" "));
+    svg.appendChild(path);
+
+    const item = document.createElement("span");
+    item.style.setProperty("--c", color);
+    item.textContent = key;
+    legend.appendChild(item);
+  }
+}
+
+async function reauth() {
+  const button = document.getElementById("reauth");
+  button.disabled = true;
+  try {
+    await api("/v1/session/reauth", { method: "POST" });
+  } catch (e) {
+    alert(e.message);
+  }
+  setTimeout(loadStatus, 1000);
+}
+
+async function refresh() {
+  const loaders = [loadStatus, loadNetWorth, loadAccounts, loadPositions, loadTransactions];
+  await Promise.all(loaders.map((l) => l().catch((e) => console.warn(l.name, e.message))));
+}
+
+document.getElementById("reauth").addEventListener("click", reauth);
+document.getElementById("history-series").addEventListener("change", loadHistory);
+document.getElementById("history-range").addEventListener("change", loadHistory);
+
+refresh();
+loadHistory().catch((e) => console.warn("history", e.message));
+setInterval(refresh, 30000);
+setInterval(loadStatus, 5000);
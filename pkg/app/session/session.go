@@ -6,15 +6,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	"sync"
 
 	"github.com/kaedwen/trade/pkg/app/client"
-	api_error "github.com/kaedwen/trade/pkg/app/error"
+	"github.com/kaedwen/trade/pkg/app/logging"
+	"github.com/kaedwen/trade/pkg/app/tan"
 	"github.com/kaedwen/trade/pkg/app/utils"
 	"github.com/kaedwen/trade/pkg/config"
 )
@@ -25,15 +23,34 @@ const (
 	ApiSessionActivatePath = "/session/clients/user/v1/sessions/%s"
 )
 
+// ErrSessionInactive means the session of a stored token is no longer TAN
+// activated.
+var ErrSessionInactive = errors.New("session not tan activated")
+
 type Session interface {
 	Init(context.Context) error
+	Login(context.Context, client.Client) (context.Context, error)
 	NewRequestInfo() string
+	Id() string
+	State() State
+	Active() bool
+	Observe(Observer)
+	Expire() bool
+	Reused() bool
 }
 
 type session struct {
-	cfg         *config.Config
-	sessionId   string
-	challengeId string
+	cfg       *config.Config
+	approver  tan.Approver
+	sessionId string
+	reused    bool
+	challenge *tan.Challenge
+
+	// notify keeps observers called in the order of the transitions
+	notify    sync.Mutex
+	mu        sync.Mutex
+	state     State
+	observers []Observer
 }
 
 type sessionData struct {
@@ -42,13 +59,6 @@ type sessionData struct {
 	Activated2FA     bool   `json:"activated2FA"`
 }
 
-type sessionAuthenticationInfo struct {
-	Id             string   `json:"id,omitempty"`
-	Type           *string  `json:"typ,omitempty"`
-	Challenge      string   `json:"challenge,omitempty"`
-	AvailableTypes []string `json:"availableTypes,omitempty"`
-}
-
 type requestInfo struct {
 	ClientRequestId clientRequestId `json:"clientRequestId"`
 }
@@ -58,160 +68,254 @@ type clientRequestId struct {
 	RequestId string `json:"requestId"`
 }
 
-func NewSession(cfg *config.Config) Session {
+func NewSession(cfg *config.Config, approver tan.Approver) Session {
 	return &session{
-		cfg: cfg,
+		cfg:      cfg,
+		approver: approver,
+		state:    StateDisconnected,
+	}
+}
+
+// Login continues the session of a stored token if it is still TAN
+// activated. Otherwise it runs the oauth password grant on c, activates a
+// session with TAN and upgrades the token with the cd_secondary flow. The
+// client is updated in place, so contexts carrying it keep working after a
+// re-login.
+func (s *session) Login(ctx context.Context, c client.Client) (context.Context, error) {
+	s.transition(StateAuthenticating)
+
+	ctx, err := s.login(ctx, c)
+	if err != nil {
+		s.transition(StateDisconnected)
+		return nil, err
+	}
+
+	s.transition(StateActive)
+	return ctx, nil
+}
+
+func (s *session) login(ctx context.Context, c client.Client) (context.Context, error) {
+	rctx, err := s.resume(ctx, c)
+	if err == nil {
+		return rctx, nil
+	} else if !errors.Is(err, client.ErrNoToken) {
+		slog.Info("stored token not usable, log in again", "error", err)
+	}
+
+	ctx, err = c.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Init(ctx); err != nil {
+		return nil, err
+	}
+
+	return client.FromContext(ctx).OAuthSecondFlow(ctx)
+}
+
+// resume refreshes the stored token and uses it if its session is still TAN
+// activated, no TAN is needed then.
+func (s *session) resume(ctx context.Context, c client.Client) (context.Context, error) {
+	ctx, err := c.Restore(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.aquireSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !active {
+		return nil, ErrSessionInactive
 	}
+
+	s.mu.Lock()
+	s.reused = true
+	s.mu.Unlock()
+
+	slog.Info("resume tan activated session")
+	return ctx, nil
 }
 
 func (s *session) Init(ctx context.Context) error {
-	if err := s.aquireSession(ctx); err != nil {
+	active, err := s.aquireSession(ctx)
+	if err != nil {
 		return err
 	}
 
+	s.mu.Lock()
+	s.reused = active
+	s.mu.Unlock()
+
+	if active {
+		slog.Info("reuse tan activated session")
+		return nil
+	}
+
 	if err := s.validateSessionTan(ctx); err != nil {
 		return err
 	}
 
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGHUP)
-
-	log.Println("wait 30s for TAN challenge (or SIGHUP) ...")
-	select {
-	case <-signals:
-	case <-time.After(30 * time.Second):
-	}
+	s.transition(StateAwaitingTan)
 
-	close(signals)
+	s.mu.Lock()
+	challenge := s.challenge
+	s.mu.Unlock()
 
-	if err := s.activateSession(ctx); err != nil {
+	code, err := s.approver.Approve(ctx, challenge)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.activateSession(ctx, code)
 }
 
 func (s *session) Id() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	return s.sessionId
 }
 
-func (s *session) aquireSession(ctx context.Context) error {
-	log.Println("aquire session")
+// Reused reports whether the current session was already TAN activated when
+// it was acquired, e.g. resumed from the stored token, so no TAN was asked
+// for.
+func (s *session) Reused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reused
+}
+
+// aquireSession picks the session to use, preferring one that is already TAN
+// activated, and reports whether it is.
+func (s *session) aquireSession(ctx context.Context) (bool, error) {
+	slog.Info("aquire session")
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.ApiAddress.JoinPath(ApiSessionUserPath).String(), nil)
 	if err != nil {
-		return err
+		return false, err
 	}
 	req.Header.Add("x-http-request-info", newRequestInfo(utils.RandString(100)))
 
-	resp, err := client.FromContext(ctx).Do(req)
+	resp, err := client.FromContext(ctx).Do(req, client.WithEndpoint(ApiSessionUserPath))
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Println(utils.ReadAllString(resp.Body))
-		return api_error.ErrApiBadStatus
+		return false, client.BadStatus(resp)
 	}
 
 	var sessionDataList []sessionData
 	if err := json.NewDecoder(resp.Body).Decode(&sessionDataList); err != nil {
-		return err
+		return false, err
 	}
 
 	if len(sessionDataList) == 0 {
-		return errors.New("no session objects received")
+		return false, errors.New("no session objects received")
 	}
 
-	s.sessionId = sessionDataList[0].Identifier
+	selected := sessionDataList[0]
+	for _, d := range sessionDataList {
+		if d.active() {
+			selected = d
+			break
+		}
+	}
 
-	return nil
+	slog.Info("session selected", "sessions", len(sessionDataList), "tan_active", selected.active())
+
+	logging.SetSecret("session", selected.Identifier)
+
+	s.mu.Lock()
+	s.sessionId = selected.Identifier
+	s.mu.Unlock()
+
+	return selected.active(), nil
+}
+
+// active reports whether the session was already activated with a TAN.
+func (d sessionData) active() bool {
+	return d.SessionTanActive && d.Activated2FA
 }
 
 func (s *session) validateSessionTan(ctx context.Context) error {
-	log.Println("validate session")
+	slog.Info("validate session")
 
+	id := s.Id()
 	data, _ := json.Marshal(sessionData{
-		Identifier:       s.sessionId,
+		Identifier:       id,
 		SessionTanActive: true,
 		Activated2FA:     true,
 	})
 
-	req, err := http.NewRequest(http.MethodPost, s.cfg.ApiAddress.JoinPath(fmt.Sprintf(ApiSessionValidatePath, s.sessionId)).String(), bytes.NewBuffer(data))
+	req, err := http.NewRequest(http.MethodPost, s.cfg.ApiAddress.JoinPath(fmt.Sprintf(ApiSessionValidatePath, id)).String(), bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
 	req.Header.Add("x-http-request-info", s.NewRequestInfo())
 
-	resp, err := client.FromContext(ctx).Do(req)
+	resp, err := client.FromContext(ctx).Do(req, client.WithEndpoint(ApiSessionValidatePath))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		log.Println(utils.ReadAllString(resp.Body))
-		return api_error.ErrApiBadStatus
-	}
-
-	value := resp.Header.Get("x-once-authentication-info")
-	if len(value) == 0 {
-		return errors.New("no authentication-info received")
+		return client.BadStatus(resp)
 	}
 
-	var authenticationInfo sessionAuthenticationInfo
-	if err := json.Unmarshal([]byte(value), &authenticationInfo); err != nil {
-		return errors.New("failed to parse authentication-info")
+	challenge, err := tan.FromResponse(resp)
+	if err != nil {
+		return err
 	}
 
-	s.challengeId = authenticationInfo.Id
+	s.mu.Lock()
+	s.challenge = challenge
+	s.mu.Unlock()
 
 	return nil
 }
 
-func (s *session) activateSession(ctx context.Context) error {
-	log.Println("activate session")
+func (s *session) activateSession(ctx context.Context, code string) error {
+	slog.Info("activate session")
+
+	s.mu.Lock()
+	id, challenge := s.sessionId, s.challenge
+	s.mu.Unlock()
 
 	data, _ := json.Marshal(sessionData{
-		Identifier:       s.sessionId,
+		Identifier:       id,
 		SessionTanActive: true,
 		Activated2FA:     true,
 	})
 
-	req, err := http.NewRequest(http.MethodPatch, s.cfg.ApiAddress.JoinPath(fmt.Sprintf(ApiSessionActivatePath, s.sessionId)).String(), bytes.NewBuffer(data))
+	req, err := http.NewRequest(http.MethodPatch, s.cfg.ApiAddress.JoinPath(fmt.Sprintf(ApiSessionActivatePath, id)).String(), bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
 	req.Header.Add("x-http-request-info", s.NewRequestInfo())
-	req.Header.Add("x-once-authentication-info", newAuthenticationInfo(s.challengeId))
+	req.Header.Add(tan.HeaderAuthenticationInfo, challenge.Header(code))
 
-	resp, err := client.FromContext(ctx).Do(req)
+	resp, err := client.FromContext(ctx).Do(req, client.WithEndpoint(ApiSessionActivatePath))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Println(utils.ReadAllString(resp.Body))
-		return api_error.ErrApiBadStatus
+		return client.BadStatus(resp)
 	}
 
 	return nil
 }
 
 func (s *session) NewRequestInfo() string {
-	return newRequestInfo(s.sessionId)
-}
-
-func newAuthenticationInfo(challengeId string) string {
-	ri := sessionAuthenticationInfo{
-		Id: challengeId,
-	}
-
-	rid, _ := json.Marshal(ri)
-
-	return string(rid)
+	return newRequestInfo(s.Id())
 }
 
 func newRequestInfo(sessionId string) string {
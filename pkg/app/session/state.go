@@ -0,0 +1,80 @@
+package session
+
+import (
+	"github.com/kaedwen/trade/pkg/app/metrics"
+)
+
+type State string
+
+const (
+	StateDisconnected   State = "Disconnected"
+	StateAuthenticating State = "Authenticating"
+	StateAwaitingTan    State = "AwaitingTAN"
+	StateActive         State = "Active"
+	StateExpired        State = "Expired"
+)
+
+var states = []State{StateDisconnected, StateAuthenticating, StateAwaitingTan, StateActive, StateExpired}
+
+// Observer is called on every state transition, in order and outside of any
+// session lock.
+type Observer func(from, to State)
+
+func (s *session) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state
+}
+
+func (s *session) Active() bool {
+	return s.State() == StateActive
+}
+
+func (s *session) Observe(o Observer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.observers = append(s.observers, o)
+}
+
+// Expire marks an active session as lost, e.g. after the API answered 401.
+// It reports whether the state changed.
+func (s *session) Expire() bool {
+	return s.transition(StateExpired, StateActive)
+}
+
+// transition moves to the given state, when from is given only out of one of
+// these states.
+func (s *session) transition(to State, from ...State) bool {
+	s.notify.Lock()
+	defer s.notify.Unlock()
+
+	s.mu.Lock()
+	prev := s.state
+	allowed := len(from) == 0
+	for _, f := range from {
+		allowed = allowed || f == prev
+	}
+	if !allowed || prev == to {
+		s.mu.Unlock()
+		return false
+	}
+	s.state = to
+	observers := s.observers
+	s.mu.Unlock()
+
+	for _, st := range states {
+		v := 0.0
+		if st == to {
+			v = 1
+		}
+		metrics.SessionState.With(string(st)).Set(v)
+	}
+
+	for _, o := range observers {
+		o(prev, to)
+	}
+
+	return true
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/metrics"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+// logEvents writes a line for every change and a summary for every snapshot.
+func (a *Application) logEvents(ctx context.Context) {
+	a.bus.Subscribe(ctx, func(e event.Event) {
+		switch data := e.Data.(type) {
+		case *event.Snapshot:
+			nw, err := data.Balances.NetWorth()
+			if err != nil {
+				slog.Error("failed to compute net worth", "error", err)
+				return
+			}
+
+			for _, g := range nw.Groups {
+				slog.Info("net worth group", "type", g.ProductType, "text", g.Text, "count", g.Count, "value", g.Value, "unit", nw.Unit)
+			}
+			slog.Info("net worth", "value", nw.Total, "unit", nw.Unit)
+		case event.BalanceChange:
+			slog.Info("balance changed", "product", data.ProductId, "from", data.Previous.Value, "to", data.Current.Value, "unit", data.Current.Unit)
+		case event.TransactionArrival:
+			slog.Info("new transaction", "account", data.AccountId, "amount", data.Transaction.Amount.Value, "unit", data.Transaction.Amount.Unit, "type", data.Transaction.TransactionType.Text)
+		case event.PositionChange:
+			slog.Info("position changed", "event", e.Type, "instrument", data.InstrumentId, "depot", data.DepotId, "quantity", data.Quantity.Value)
+		case event.PriceMove:
+			slog.Info("price moved", "instrument", data.InstrumentId, "change", data.Change, "to", data.Current.Value, "unit", data.Current.Unit)
+		case event.DocumentArrival:
+			slog.Info("new document", "document", data.Document.DocumentId, "name", data.Document.Name)
+		case event.TanChallenge:
+			slog.Warn("TAN approval needed", "type", data.Type, "challenge", data.Id)
+		case event.Alert:
+			slog.Warn("alert", "event", e.Type, "rule", data.Rule, "severity", data.Severity, "message", data.Message)
+		case event.SessionState:
+			slog.Info("session changed", "state", data.State)
+		case event.LoginLock:
+			slog.Error("login locked", "error", data.Error)
+		}
+	})
+}
+
+// storeEvents persists snapshots and the lifecycle timeline of all orders.
+func (a *Application) storeEvents(ctx context.Context) {
+	a.bus.Subscribe(ctx, func(e event.Event) {
+		var err error
+
+		switch data := e.Data.(type) {
+		case *event.Snapshot:
+			err = a.storeSnapshot(data)
+		case event.OrderStateChange:
+			err = a.store.AppendRecord(store.SeriesOrders, data.OrderId, e)
+		}
+
+		if err != nil {
+			slog.Error("failed to store event", "event", e.Type, "error", err)
+		}
+	}, event.SnapshotFetched, event.OrderStateChanged)
+}
+
+// storeSnapshot persists balances, depot totals and positions of one fetch.
+func (a *Application) storeSnapshot(s *event.Snapshot) error {
+	var balances, depots, pos []store.Sample
+	t := s.Time
+
+	for _, v := range s.Balances.Values {
+		switch v.ProductType {
+		case model.ProductTypeDepot:
+			b, err := v.DepotBalance()
+			if err != nil {
+				return err
+			}
+			depots = append(depots, store.Sample{Time: t, Key: b.DepotId, Values: map[string]float64{
+				"currentValue":          b.CurrentValue.Value,
+				"purchaseValue":         b.PurchaseValue.Value,
+				"prevDayValue":          b.PrevDayValue.Value,
+				"profitLossPurchaseAbs": b.ProfitLossPurchaseAbs.Value,
+				"profitLossPrevDayAbs":  b.ProfitLossPrevDayAbs.Value,
+			}})
+		case model.ProductTypeCard:
+			b, err := v.CardBalance()
+			if err != nil {
+				return err
+			}
+			balances = append(balances, store.Sample{Time: t, Key: b.CardId, Values: map[string]float64{
+				"balance":             b.Balance.Value,
+				"availableCashAmount": b.AvailableCashAmount.Value,
+			}})
+		default:
+			b, err := v.AccountBalance()
+			if err != nil {
+				return err
+			}
+			balances = append(balances, store.Sample{Time: t, Key: b.AccountID, Values: map[string]float64{
+				"balance":                b.Balance.Value,
+				"balanceEUR":             b.BalanceEUR.Value,
+				"availableCashAmount":    b.AvailableCashAmount.Value,
+				"availableCashAmountEUR": b.AvailableCashAmountEUR.Value,
+				"creditLimit":            b.Account.CreditLimit.Value,
+			}})
+		}
+	}
+
+	for _, p := range s.Positions {
+		pos = append(pos, store.Sample{Time: t, Key: p.DepotId + "/" + p.InstrumentId, Values: map[string]float64{
+			"quantity":              p.Quantity.Value,
+			"price":                 p.CurrentPrice.Price.Value,
+			"currentValue":          p.CurrentValue.Value,
+			"purchaseValue":         p.PurchaseValue.Value,
+			"profitLossPurchaseAbs": p.ProfitLossPurchaseAbs.Value,
+			"profitLossPrevDayRel":  p.ProfitLossPrevDayRel,
+		}})
+	}
+
+	// closed accounts and sold positions end their series
+	if err := a.store.Replace(store.SeriesBalances, t, balances...); err != nil {
+		return err
+	}
+
+	if err := a.store.Replace(store.SeriesDepots, t, depots...); err != nil {
+		return err
+	}
+
+	return a.store.Replace(store.SeriesPositions, t, pos...)
+}
+
+// metricEvents mirrors every snapshot into the exported gauges. Series are
+// reset first so closed accounts and sold positions disappear.
+func (a *Application) metricEvents(ctx context.Context) {
+	a.bus.Subscribe(ctx, func(e event.Event) {
+		s := e.Data.(*event.Snapshot)
+
+		metrics.AccountBalance.Reset()
+		metrics.AccountAvailableCash.Reset()
+		metrics.DepotValue.Reset()
+		metrics.PositionValue.Reset()
+		metrics.PositionProfitLoss.Reset()
+
+		for _, v := range s.Balances.Values {
+			switch v.ProductType {
+			case model.ProductTypeDepot:
+				b, err := v.DepotBalance()
+				if err != nil {
+					slog.Error("failed to export depot balance", "error", err)
+					continue
+				}
+				metrics.DepotValue.With(b.DepotId, b.CurrentValue.Unit).Set(b.CurrentValue.Value)
+			case model.ProductTypeCard:
+				b, err := v.CardBalance()
+				if err != nil {
+					slog.Error("failed to export card balance", "error", err)
+					continue
+				}
+				metrics.AccountBalance.With(b.CardId, string(v.ProductType), b.Balance.Unit).Set(b.Balance.Value)
+				metrics.AccountAvailableCash.With(b.CardId, string(v.ProductType), b.AvailableCashAmount.Unit).Set(b.AvailableCashAmount.Value)
+			default:
+				b, err := v.AccountBalance()
+				if err != nil {
+					slog.Error("failed to export account balance", "error", err)
+					continue
+				}
+				metrics.AccountBalance.With(b.AccountID, b.Account.AccountType.Key, b.Balance.Unit).Set(b.Balance.Value)
+				metrics.AccountAvailableCash.With(b.AccountID, b.Account.AccountType.Key, b.AvailableCashAmount.Unit).Set(b.AvailableCashAmount.Value)
+			}
+		}
+
+		for _, p := range s.Positions {
+			metrics.PositionValue.With(p.DepotId, p.InstrumentId, p.Wkn, p.CurrentValue.Unit).Set(p.CurrentValue.Value)
+			metrics.PositionProfitLoss.With(p.DepotId, p.InstrumentId, p.Wkn, p.ProfitLossPurchaseAbs.Unit).Set(p.ProfitLossPurchaseAbs.Value)
+		}
+	}, event.SnapshotFetched)
+}
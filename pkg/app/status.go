@@ -0,0 +1,68 @@
+package app
+
+import (
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/event"
+	"github.com/kaedwen/trade/pkg/app/lockout"
+	"github.com/kaedwen/trade/pkg/app/scheduler"
+	"github.com/kaedwen/trade/pkg/app/session"
+)
+
+type Status struct {
+	Session     SessionStatus         `json:"session"`
+	Login       *lockout.State        `json:"login,omitempty"`
+	TokenExpiry time.Time             `json:"tokenExpiry,omitempty"`
+	Jobs        []scheduler.JobStatus `json:"jobs"`
+	SnapshotAt  time.Time             `json:"snapshotFetchedAt,omitempty"`
+	DocumentsAt time.Time             `json:"documentsFetchedAt,omitempty"`
+}
+
+// SessionStatus leaves out the id of the session, which is a secret like the
+// token.
+type SessionStatus struct {
+	State  session.State `json:"state"`
+	Active bool          `json:"active"`
+	// PendingTan is the challenge waiting for approval, if any.
+	PendingTan *event.TanChallenge `json:"pendingTan,omitempty"`
+	Reauth     bool                `json:"reauth"`
+	// Reused is set when the session was already TAN activated on login.
+	Reused bool `json:"reused"`
+}
+
+// Status summarizes session, token and the freshness of the fetched data.
+func (a *Application) Status() Status {
+	status := Status{
+		Session: SessionStatus{
+			State:      a.State(),
+			Active:     a.Active(),
+			PendingTan: a.pending.Load(),
+			Reauth:     a.reauth.Load(),
+			Reused:     a.Reused(),
+		},
+	}
+
+	c, guard := a.loggedIn()
+
+	if guard != nil {
+		if s, err := guard.State(); err == nil {
+			status.Login = &s
+		}
+	}
+
+	if c != nil {
+		status.TokenExpiry = c.Expiry()
+	}
+
+	if a.scheduler != nil {
+		status.Jobs = a.scheduler.Status()
+	}
+
+	if snapshot := a.cache.Snapshot(); snapshot != nil {
+		status.SnapshotAt = snapshot.Time
+	}
+
+	_, status.DocumentsAt = a.cache.Documents()
+
+	return status
+}
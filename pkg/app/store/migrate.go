@@ -0,0 +1,122 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SchemaVersion is the layout version written by this release. Every change
+// of the layout appends a migration.
+const SchemaVersion = 2
+
+const (
+	metaFile   = "meta.json"
+	seriesDir  = "series"
+	recordsDir = "records"
+	stateDir   = "state"
+)
+
+type meta struct {
+	Version int `json:"version"`
+}
+
+type migration struct {
+	version     int
+	description string
+	apply       func(dir string) error
+}
+
+var migrations = []migration{
+	{1, "initial layout", func(dir string) error {
+		for _, d := range []string{seriesDir, recordsDir, stateDir} {
+			if err := os.MkdirAll(filepath.Join(dir, d), 0o750); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{2, "monthly series segments", splitSeries},
+}
+
+// splitSeries moves every series file into monthly segments, which start with
+// a checkpoint of the values valid at their start.
+func splitSeries(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, seriesDir, "*.jsonl"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		series := Series(strings.TrimSuffix(filepath.Base(file), ".jsonl"))
+
+		// a segment left by an interrupted migration is written again
+		if err := os.RemoveAll(filepath.Join(dir, seriesDir, string(series))); err != nil {
+			return err
+		}
+
+		s := &store{dir: dir, files: make(map[string]*os.File)}
+		last := make(map[string]Sample)
+
+		err := scan(file, func(sample Sample) error {
+			if err := s.writeSample(series, last, sample); err != nil {
+				return err
+			}
+			last[sample.Key] = sample
+			return nil
+		})
+		if cerr := s.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to split %s - %w", series, err)
+		}
+
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrate(dir string) error {
+	var m meta
+
+	data, err := os.ReadFile(filepath.Join(dir, metaFile))
+	if err == nil {
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if m.Version > SchemaVersion {
+		return fmt.Errorf("store schema version %d is newer than supported version %d", m.Version, SchemaVersion)
+	}
+
+	for _, mi := range migrations {
+		if mi.version <= m.Version {
+			continue
+		}
+
+		slog.Info("migrate store", "version", mi.version, "description", mi.description)
+
+		if err := mi.apply(dir); err != nil {
+			return err
+		}
+
+		m.Version = mi.version
+		data, _ := json.Marshal(m)
+		if err := writeFile(filepath.Join(dir, metaFile), data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,514 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Series string
+
+const (
+	SeriesBalances  Series = "balances"
+	SeriesDepots    Series = "depots"
+	SeriesPositions Series = "positions"
+	SeriesOrders    Series = "orders"
+)
+
+var ErrNoState = errors.New("no state stored")
+
+// Sample is a snapshot of numeric values of one account, depot or position.
+// A sample without values marks the end of its key, e.g. a sold position.
+type Sample struct {
+	Time   time.Time          `json:"t"`
+	Key    string             `json:"k"`
+	Values map[string]float64 `json:"v"`
+	// Checkpoint repeats the values valid at the start of a segment, so a
+	// query does not need to read the segments before.
+	Checkpoint bool `json:"c,omitempty"`
+}
+
+// Removed reports whether the sample ends its key.
+func (s Sample) Removed() bool {
+	return len(s.Values) == 0
+}
+
+// Record is an arbitrary document of a timeline, e.g. an order event.
+type Record struct {
+	Time time.Time       `json:"t"`
+	Key  string          `json:"k"`
+	Data json.RawMessage `json:"d"`
+}
+
+type Query struct {
+	Series Series
+	// Key is a glob pattern (see path.Match), empty matches all keys.
+	Key  string
+	From time.Time
+	To   time.Time
+	// Resolution downsamples to the last sample per key and interval.
+	Resolution time.Duration
+}
+
+type Store interface {
+	Append(Series, ...Sample) error
+	Replace(Series, time.Time, ...Sample) error
+	Query(Query) ([]Sample, error)
+	AppendRecord(Series, string, any) error
+	Records(Query) ([]Record, error)
+	Load(string, any) error
+	Save(string, any) error
+	Close() error
+}
+
+type store struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*os.File
+	last  map[Series]map[string]Sample
+}
+
+// Open opens the store in dir, creating or migrating it to the current
+// schema version when necessary.
+func Open(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	if err := migrate(dir); err != nil {
+		return nil, fmt.Errorf("failed to migrate store - %w", err)
+	}
+
+	return &store{
+		dir:   dir,
+		files: make(map[string]*os.File),
+		last:  make(map[Series]map[string]Sample),
+	}, nil
+}
+
+// Append persists the samples, skipping those equal to the last known values
+// of their key.
+func (s *store) Append(series Series, samples ...Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last, err := s.lastSamples(series)
+	if err != nil {
+		return err
+	}
+
+	for _, sample := range samples {
+		if l, ok := last[sample.Key]; ok && maps.Equal(l.Values, sample.Values) {
+			continue
+		}
+
+		if err := s.writeSample(series, last, sample); err != nil {
+			return err
+		}
+		last[sample.Key] = sample
+	}
+
+	return nil
+}
+
+// Replace persists the samples as the complete set of the series at t. Keys
+// missing from it are ended, so their last values are not carried forward.
+func (s *store) Replace(series Series, t time.Time, samples ...Sample) error {
+	if err := s.Append(series, samples...); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.last[series]
+	for _, key := range slices.Sorted(maps.Keys(last)) {
+		if last[key].Removed() || slices.ContainsFunc(samples, func(sample Sample) bool { return sample.Key == key }) {
+			continue
+		}
+
+		end := Sample{Time: t, Key: key, Values: map[string]float64{}}
+		if err := s.writeSample(series, last, end); err != nil {
+			return err
+		}
+		last[key] = end
+	}
+
+	return nil
+}
+
+// Query reads the segments from the one covering From on, the checkpoint of
+// that segment provides the values valid before From.
+func (s *store) Query(q Query) ([]Sample, error) {
+	segments, err := s.segments(q.Series)
+	if err != nil {
+		return nil, err
+	}
+
+	first := 0
+	if !q.From.IsZero() {
+		// the last segment starting at or before From
+		i, found := slices.BinarySearch(segments, segmentOf(q.From))
+		if !found {
+			i--
+		}
+		first = max(i, 0)
+	}
+
+	var samples []Sample
+	before := make(map[string]Sample)
+
+	for i, name := range segments[first:] {
+		if !q.To.IsZero() && name > segmentOf(q.To) {
+			break
+		}
+
+		err := scan(filepath.Join(s.dir, seriesDir, string(q.Series), name+".jsonl"), func(sample Sample) error {
+			if sample.Checkpoint && (i > 0 || q.From.IsZero()) {
+				// the values are known from the segments read before
+				return nil
+			}
+			sample.Checkpoint = false
+
+			samples = q.filter(sample, samples, before)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// unchanged values are not stored, so the value valid at the start of the
+	// range is carried in
+	for _, key := range slices.Sorted(maps.Keys(before)) {
+		sample := before[key]
+		sample.Time = q.From
+		samples = append(samples, sample)
+	}
+
+	slices.SortStableFunc(samples, func(a, b Sample) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	if q.Resolution > 0 {
+		samples = downsample(samples, q.Resolution)
+	}
+
+	return samples, nil
+}
+
+// filter adds a sample within the range of q to samples and keeps the last
+// one of every key before the range in before.
+func (q Query) filter(sample Sample, samples []Sample, before map[string]Sample) []Sample {
+	if !matchKey(q.Key, sample.Key) {
+		return samples
+	}
+
+	if !q.From.IsZero() && sample.Time.Before(q.From) {
+		if sample.Removed() {
+			delete(before, sample.Key)
+		} else {
+			before[sample.Key] = sample
+		}
+		return samples
+	}
+
+	if !q.To.IsZero() && sample.Time.After(q.To) {
+		return samples
+	}
+
+	return append(samples, sample)
+}
+
+func (s *store) AppendRecord(series Series, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.write(filepath.Join(recordsDir, string(series)+".jsonl"), Record{time.Now(), key, data})
+}
+
+func (s *store) Records(q Query) ([]Record, error) {
+	var records []Record
+
+	err := scan(filepath.Join(s.dir, recordsDir, string(q.Series)+".jsonl"), func(r Record) error {
+		if matchKey(q.Key, r.Key) && (q.From.IsZero() || !r.Time.Before(q.From)) && (q.To.IsZero() || !r.Time.After(q.To)) {
+			records = append(records, r)
+		}
+		return nil
+	})
+
+	return records, err
+}
+
+// Load reads the named state document into v, ErrNoState if there is none.
+func (s *store) Load(name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, stateDir, name+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNoState
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// Save atomically replaces the named state document.
+func (s *store) Save(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(s.dir, stateDir, name+".json"), data)
+}
+
+func (s *store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, f := range s.files {
+		errs = append(errs, f.Close())
+	}
+	clear(s.files)
+
+	return errors.Join(errs...)
+}
+
+// lastSamples returns the last sample of every key, read from the latest
+// segment which starts with a checkpoint of all keys.
+func (s *store) lastSamples(series Series) (map[string]Sample, error) {
+	if last, ok := s.last[series]; ok {
+		return last, nil
+	}
+
+	segments, err := s.segments(series)
+	if err != nil {
+		return nil, err
+	}
+
+	last := make(map[string]Sample)
+	if len(segments) > 0 {
+		err := scan(filepath.Join(s.dir, seriesDir, string(series), segments[len(segments)-1]+".jsonl"), func(sample Sample) error {
+			last[sample.Key] = sample
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.last[series] = last
+	return last, nil
+}
+
+// segments lists the monthly segments of the series in order.
+func (s *store) segments(series Series) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, seriesDir, string(series)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var segments []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".jsonl"); ok && !e.IsDir() {
+			segments = append(segments, name)
+		}
+	}
+	slices.Sort(segments)
+
+	return segments, nil
+}
+
+// writeSample appends the sample to the segment of its month. A new segment
+// starts with a checkpoint of the last values, the segments before are
+// closed.
+func (s *store) writeSample(series Series, last map[string]Sample, sample Sample) error {
+	segment := segmentOf(sample.Time)
+	dir := filepath.Join(seriesDir, string(series))
+	name := filepath.Join(dir, segment+".jsonl")
+
+	if _, ok := s.files[name]; !ok {
+		if err := os.MkdirAll(filepath.Join(s.dir, dir), 0o750); err != nil {
+			return err
+		}
+
+		_, err := os.Stat(filepath.Join(s.dir, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if err != nil {
+			start, _ := time.Parse(segmentLayout, segment)
+			for _, key := range slices.Sorted(maps.Keys(last)) {
+				if l := last[key]; !l.Removed() {
+					if err := s.write(name, Sample{Time: start, Key: key, Values: l.Values, Checkpoint: true}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		for n, f := range s.files {
+			if n != name && filepath.Dir(n) == dir {
+				f.Close()
+				delete(s.files, n)
+			}
+		}
+	}
+
+	return s.write(name, sample)
+}
+
+const segmentLayout = "2006-01"
+
+func segmentOf(t time.Time) string {
+	return t.UTC().Format(segmentLayout)
+}
+
+func (s *store) write(name string, v any) error {
+	f, ok := s.files[name]
+	if !ok {
+		var err error
+		f, err = os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o640)
+		if err != nil {
+			return err
+		}
+		if err := truncatePartial(f); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to repair %s - %w", name, err)
+		}
+		s.files[name] = f
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// truncatePartial removes a partially written last line, e.g. after a crash,
+// so the next append does not continue it.
+func truncatePartial(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4096)
+	for end := info.Size(); end > 0; {
+		start := max(0, end-int64(len(buf)))
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			if pos := start + int64(i) + 1; pos < info.Size() {
+				return f.Truncate(pos)
+			}
+			return nil
+		}
+
+		end = start
+	}
+
+	// no complete line at all
+	if info.Size() > 0 {
+		return f.Truncate(0)
+	}
+
+	return nil
+}
+
+func scan[T any](name string, fn func(T) error) error {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for sc.Scan() {
+		var v T
+		// a partially written last line is skipped
+		if err := json.Unmarshal(sc.Bytes(), &v); err != nil {
+			continue
+		}
+
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return sc.Err()
+}
+
+func downsample(samples []Sample, resolution time.Duration) []Sample {
+	type bucket struct {
+		key  string
+		time time.Time
+	}
+
+	index := make(map[bucket]int)
+	var out []Sample
+
+	for _, sample := range samples {
+		b := bucket{sample.Key, sample.Time.Truncate(resolution)}
+		if i, ok := index[b]; ok {
+			out[i].Values = sample.Values
+			continue
+		}
+
+		index[b] = len(out)
+		out = append(out, Sample{Time: b.time, Key: sample.Key, Values: sample.Values})
+	}
+
+	return out
+}
+
+func matchKey(pattern, key string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		return pattern == key
+	}
+
+	ok, _ := path.Match(pattern, key)
+	return ok
+}
+
+func writeFile(name string, data []byte) error {
+	if err := os.WriteFile(name+".tmp", data, 0o640); err != nil {
+		return err
+	}
+
+	return os.Rename(name+".tmp", name)
+}
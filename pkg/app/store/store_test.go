@@ -0,0 +1,210 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2026, month, d, 12, 0, 0, 0, time.UTC)
+}
+
+func values(x float64) map[string]float64 {
+	return map[string]float64{"x": x}
+}
+
+func TestQuery(t *testing.T) {
+	// a rises over three months, b is sold in april
+	fetches := []struct {
+		time    time.Time
+		samples []Sample
+	}{
+		{day(1, 10), []Sample{{Key: "a", Values: values(1)}, {Key: "b", Values: values(5)}}},
+		{day(1, 20), []Sample{{Key: "a", Values: values(1)}, {Key: "b", Values: values(5)}}},
+		{day(3, 5), []Sample{{Key: "a", Values: values(2)}, {Key: "b", Values: values(5)}}},
+		{day(4, 2), []Sample{{Key: "a", Values: values(3)}}},
+	}
+
+	st, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	for _, f := range fetches {
+		for i := range f.samples {
+			f.samples[i].Time = f.time
+		}
+		if err := st.Replace(SeriesPositions, f.time, f.samples...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []Sample
+	}{
+		{
+			name:  "all",
+			query: Query{},
+			want: []Sample{
+				{Time: day(1, 10), Key: "a", Values: values(1)},
+				{Time: day(1, 10), Key: "b", Values: values(5)},
+				{Time: day(3, 5), Key: "a", Values: values(2)},
+				{Time: day(4, 2), Key: "a", Values: values(3)},
+				{Time: day(4, 2), Key: "b", Values: map[string]float64{}},
+			},
+		},
+		{
+			name:  "carry in from an earlier segment",
+			query: Query{From: day(2, 15), To: day(3, 31)},
+			want: []Sample{
+				{Time: day(2, 15), Key: "a", Values: values(1)},
+				{Time: day(2, 15), Key: "b", Values: values(5)},
+				{Time: day(3, 5), Key: "a", Values: values(2)},
+			},
+		},
+		{
+			name:  "carry in from a checkpoint",
+			query: Query{From: day(3, 20)},
+			want: []Sample{
+				{Time: day(3, 20), Key: "a", Values: values(2)},
+				{Time: day(3, 20), Key: "b", Values: values(5)},
+				{Time: day(4, 2), Key: "a", Values: values(3)},
+				{Time: day(4, 2), Key: "b", Values: map[string]float64{}},
+			},
+		},
+		{
+			name:  "removed key is not carried in",
+			query: Query{From: day(4, 10)},
+			want: []Sample{
+				{Time: day(4, 10), Key: "a", Values: values(3)},
+			},
+		},
+		{
+			name:  "key pattern",
+			query: Query{Key: "b", From: day(1, 1), To: day(3, 31)},
+			want: []Sample{
+				{Time: day(1, 10), Key: "b", Values: values(5)},
+			},
+		},
+		{
+			name:  "downsampled",
+			query: Query{Key: "a", Resolution: 24 * time.Hour * 365},
+			want: []Sample{
+				{Time: day(1, 10).Truncate(24 * time.Hour * 365), Key: "a", Values: values(3)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.Series = SeriesPositions
+
+			got, err := st.Query(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		meta     string
+		series   string
+		segments map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "new store",
+			segments: map[string]string{},
+		},
+		{
+			name: "single series file",
+			meta: `{"version":1}`,
+			series: `{"t":"2026-01-10T00:00:00Z","k":"a","v":{"x":1}}
+{"t":"2026-03-05T00:00:00Z","k":"a","v":{"x":2}}
+{"t":"2026-03-06T00:00:00Z","k":"b","v":{"x":5}}
+`,
+			segments: map[string]string{
+				"2026-01.jsonl": `{"t":"2026-01-10T00:00:00Z","k":"a","v":{"x":1}}
+`,
+				"2026-03.jsonl": `{"t":"2026-03-01T00:00:00Z","k":"a","v":{"x":1},"c":true}
+{"t":"2026-03-05T00:00:00Z","k":"a","v":{"x":2}}
+{"t":"2026-03-06T00:00:00Z","k":"b","v":{"x":5}}
+`,
+			},
+		},
+		{
+			name: "partial last line",
+			meta: `{"version":1}`,
+			series: `{"t":"2026-01-10T00:00:00Z","k":"a","v":{"x":1}}
+{"t":"2026-01-11T00:00:00Z","k":"a","v":{"x"`,
+			segments: map[string]string{
+				"2026-01.jsonl": `{"t":"2026-01-10T00:00:00Z","k":"a","v":{"x":1}}
+`,
+			},
+		},
+		{
+			name:    "newer version",
+			meta:    `{"version":99}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			if len(tt.meta) > 0 {
+				if err := os.WriteFile(filepath.Join(dir, metaFile), []byte(tt.meta), 0o640); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(tt.series) > 0 {
+				if err := os.MkdirAll(filepath.Join(dir, seriesDir), 0o750); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, seriesDir, "depots.jsonl"), []byte(tt.series), 0o640); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := migrate(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := make(map[string]string)
+			files, _ := filepath.Glob(filepath.Join(dir, seriesDir, "depots", "*.jsonl"))
+			for _, f := range files {
+				data, err := os.ReadFile(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[filepath.Base(f)] = string(data)
+			}
+
+			if !reflect.DeepEqual(got, tt.segments) {
+				t.Errorf("got segments %q, want %q", got, tt.segments)
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, seriesDir, "depots.jsonl")); !os.IsNotExist(err) {
+				t.Errorf("series file left after migration")
+			}
+		})
+	}
+}
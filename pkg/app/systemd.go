@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/session"
+	"github.com/kaedwen/trade/pkg/app/systemd"
+)
+
+// sessionStatus is the service status shown by systemd per session state.
+var sessionStatus = map[session.State]string{
+	session.StateDisconnected:   "login failed, retrying",
+	session.StateAuthenticating: "logging in",
+	session.StateAwaitingTan:    "waiting for TAN approval",
+	session.StateActive:         "session active",
+	session.StateExpired:        "session expired, logging in again",
+}
+
+// notifySystemd reports the session state to systemd, the service is ready
+// once the first session is active.
+func (a *Application) notifySystemd() {
+	a.Observe(func(from, to session.State) {
+		state := []string{systemd.Status(sessionStatus[to])}
+		if to == session.StateActive {
+			state = append(state, systemd.Ready)
+		}
+
+		if err := systemd.Notify(state...); err != nil {
+			slog.Warn("failed to notify systemd", "error", err)
+		}
+	})
+}
+
+// watchdog pings the systemd watchdog as long as jobs succeed. Without an
+// active session the jobs are held and waiting for the login, e.g. for its
+// TAN, is not considered stuck.
+func (a *Application) watchdog(ctx context.Context) {
+	interval := systemd.WatchdogInterval()
+	if interval == 0 {
+		return
+	}
+
+	start := time.Now()
+
+	t := time.NewTicker(interval / 2)
+	defer t.Stop()
+
+	for {
+		if a.healthy(start, interval) {
+			if err := systemd.Notify(systemd.Watchdog); err != nil {
+				slog.Warn("failed to notify systemd watchdog", "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// healthy reports whether a job succeeded within the interval, jobs get the
+// interval after the service start or a login to succeed first.
+func (a *Application) healthy(start time.Time, interval time.Duration) bool {
+	if !a.Active() || a.scheduler == nil {
+		return true
+	}
+
+	latest := start
+	if t := a.activeSince.Load(); t != nil && t.After(latest) {
+		latest = *t
+	}
+
+	for _, j := range a.scheduler.Status() {
+		if j.LastSuccess.After(latest) {
+			latest = j.LastSuccess
+		}
+	}
+
+	return time.Since(latest) < interval
+}
@@ -0,0 +1,60 @@
+// Package systemd implements the parts of the sd_notify protocol used by the
+// service, without depending on libsystemd.
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Status returns the state describing the service in `systemctl status`.
+func Status(s string) string {
+	return "STATUS=" + s
+}
+
+// Notify sends the states to the service manager. It does nothing when the
+// process was not started with a notification socket.
+func Notify(state ...string) error {
+	path := os.Getenv("NOTIFY_SOCKET")
+	if len(path) == 0 {
+		return nil
+	}
+
+	// abstract socket addresses are passed with a leading @
+	if strings.HasPrefix(path, "@") {
+		path = "\x00" + path[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(strings.Join(state, "\n")))
+	return err
+}
+
+// WatchdogInterval returns the interval the service manager expects watchdog
+// pings in, zero when the watchdog is not enabled for this process.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); len(pid) > 0 && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
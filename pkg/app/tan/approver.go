@@ -0,0 +1,78 @@
+package tan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type signalApprover struct {
+	wait time.Duration
+}
+
+// NewSignalApprover waits the given duration (or until SIGHUP) for a push TAN
+// to be approved on the mobile device. Other challenges need the TAN itself,
+// which a signal cannot carry, so it waits until the context is done and
+// leaves the answer to a relay (see NewRelay).
+func NewSignalApprover(wait time.Duration) Approver {
+	return &signalApprover{wait}
+}
+
+func (sa *signalApprover) Approve(ctx context.Context, c *Challenge) (string, error) {
+	if !c.Push() {
+		slog.Info("wait for TAN (trade ctl tan <code>)", "type", c.Type, "challenge", c.Id)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	slog.Info("wait for TAN approval (or SIGHUP)", "wait", sa.wait, "type", c.Type)
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-signals:
+	case <-time.After(sa.wait):
+	}
+
+	return "", nil
+}
+
+type promptApprover struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewPromptApprover asks on the terminal for approval, or for the TAN itself
+// when the challenge is not a push TAN.
+func NewPromptApprover(in io.Reader, out io.Writer) Approver {
+	return &promptApprover{bufio.NewReader(in), out}
+}
+
+func (pa *promptApprover) Approve(ctx context.Context, c *Challenge) (string, error) {
+	if c.Push() {
+		fmt.Fprint(pa.out, "approve the TAN challenge in your app and press enter ...")
+	} else {
+		fmt.Fprintf(pa.out, "enter TAN for %s challenge: ", c.Type)
+	}
+
+	line, err := pa.in.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if c.Push() {
+		return "", nil
+	}
+
+	return strings.TrimSpace(line), nil
+}
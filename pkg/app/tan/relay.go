@@ -0,0 +1,104 @@
+package tan
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrNotPending = errors.New("no TAN challenge pending")
+	ErrTanMissing = errors.New("challenge needs a TAN")
+	ErrBusy       = errors.New("another TAN challenge is pending")
+)
+
+// Relay races an approver against answers given from outside, e.g. over the
+// control socket, whichever comes first answers the challenge. Only one
+// challenge is pending at a time, so an answer cannot reach the wrong one.
+type Relay struct {
+	approver Approver
+
+	mu        sync.Mutex
+	challenge *Challenge
+	answer    chan string
+}
+
+func NewRelay(approver Approver) *Relay {
+	return &Relay{approver: approver}
+}
+
+func (r *Relay) Approve(ctx context.Context, c *Challenge) (string, error) {
+	return r.wait(ctx, c, r.approver)
+}
+
+// Confirm only completes on an explicit Answer, never on its own. It fails
+// when the context is done first.
+func (r *Relay) Confirm(ctx context.Context, c *Challenge) (string, error) {
+	return r.wait(ctx, c, nil)
+}
+
+func (r *Relay) wait(ctx context.Context, c *Challenge, approver Approver) (string, error) {
+	answer := make(chan string, 1)
+
+	r.mu.Lock()
+	if r.challenge != nil {
+		r.mu.Unlock()
+		return "", ErrBusy
+	}
+	r.challenge, r.answer = c, answer
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.challenge, r.answer = nil, nil
+		r.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		code string
+		err  error
+	}
+
+	approved := make(chan result, 1)
+	if approver != nil {
+		go func() {
+			code, err := approver.Approve(ctx, c)
+			approved <- result{code, err}
+		}()
+	}
+
+	select {
+	case code := <-answer:
+		return code, nil
+	case res := <-approved:
+		return res.code, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Answer completes the pending challenge. Push challenges are answered
+// without TAN once approved on the device.
+func (r *Relay) Answer(code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.challenge == nil {
+		return ErrNotPending
+	}
+
+	if !r.challenge.Push() && len(code) == 0 {
+		return ErrTanMissing
+	}
+
+	select {
+	case r.answer <- code:
+	default:
+		// already answered
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package tan
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blocking approves only when its context is done.
+var blocking = ApproverFunc(func(ctx context.Context, _ *Challenge) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+})
+
+// answer answers the challenge once it is pending.
+func answer(t *testing.T, r *Relay, code string) error {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := r.Answer(code)
+		if !errors.Is(err, ErrNotPending) || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRelay(t *testing.T) {
+	tests := []struct {
+		name          string
+		typ           string
+		approver      Approver
+		confirm       bool
+		answer        *string
+		timeout       time.Duration
+		want          string
+		wantErr       error
+		wantAnswerErr error
+	}{
+		{
+			name:     "approver answers",
+			typ:      TypeMobileTan,
+			approver: ApproverFunc(func(context.Context, *Challenge) (string, error) { return "123456", nil }),
+			want:     "123456",
+		},
+		{
+			name:     "approver fails",
+			typ:      TypeMobileTan,
+			approver: ApproverFunc(func(context.Context, *Challenge) (string, error) { return "", context.Canceled }),
+			wantErr:  context.Canceled,
+		},
+		{
+			name:     "answer wins",
+			typ:      TypePhotoTan,
+			approver: blocking,
+			answer:   ptr("654321"),
+			want:     "654321",
+		},
+		{
+			name:     "push answered without tan",
+			typ:      TypePushTan,
+			approver: blocking,
+			answer:   ptr(""),
+		},
+		{
+			name:          "tan missing",
+			typ:           TypeMobileTan,
+			approver:      blocking,
+			answer:        ptr(""),
+			timeout:       50 * time.Millisecond,
+			wantErr:       context.DeadlineExceeded,
+			wantAnswerErr: ErrTanMissing,
+		},
+		{
+			name:    "confirm answered",
+			typ:     TypePushTan,
+			confirm: true,
+			answer:  ptr(""),
+		},
+		{
+			name:    "confirm times out",
+			typ:     TypePushTan,
+			confirm: true,
+			timeout: 10 * time.Millisecond,
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRelay(tt.approver)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			answered := make(chan error, 1)
+			if tt.answer != nil {
+				go func() { answered <- answer(t, r, *tt.answer) }()
+			}
+
+			wait := r.Approve
+			if tt.confirm {
+				wait = r.Confirm
+			}
+
+			code, err := wait(ctx, &Challenge{Id: "1", Type: tt.typ})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if code != tt.want {
+				t.Errorf("got code %q, want %q", code, tt.want)
+			}
+
+			if tt.answer != nil {
+				if err := <-answered; !errors.Is(err, tt.wantAnswerErr) {
+					t.Errorf("got answer error %v, want %v", err, tt.wantAnswerErr)
+				}
+			}
+
+			if err := r.Answer("1"); !errors.Is(err, ErrNotPending) {
+				t.Errorf("got error %v after completion, want %v", err, ErrNotPending)
+			}
+		})
+	}
+}
+
+func TestRelayBusy(t *testing.T) {
+	r := NewRelay(blocking)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.Approve(ctx, &Challenge{Id: "1", Type: TypeMobileTan})
+		done <- err
+	}()
+
+	// an empty answer is rejected once the first challenge is pending
+	if err := answer(t, r, ""); !errors.Is(err, ErrTanMissing) {
+		t.Fatalf("got error %v, want %v", err, ErrTanMissing)
+	}
+
+	if _, err := r.Approve(context.Background(), &Challenge{Id: "2", Type: TypeMobileTan}); !errors.Is(err, ErrBusy) {
+		t.Errorf("got error %v, want %v", err, ErrBusy)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,70 @@
+package tan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const HeaderAuthenticationInfo = "x-once-authentication-info"
+
+const (
+	TypePushTan   = "P_TAN_PUSH"
+	TypePhotoTan  = "P_TAN"
+	TypeMobileTan = "M_TAN"
+)
+
+var ErrNoChallenge = errors.New("no authentication-info received")
+
+type Challenge struct {
+	Id             string   `json:"id,omitempty"`
+	Type           string   `json:"typ,omitempty"`
+	Challenge      string   `json:"challenge,omitempty"`
+	AvailableTypes []string `json:"availableTypes,omitempty"`
+}
+
+type authenticationInfo struct {
+	Id  string `json:"id"`
+	Tan string `json:"tan,omitempty"`
+}
+
+// Approver waits until the given challenge has been approved by the user and
+// returns the TAN to submit (empty for push TAN).
+type Approver interface {
+	Approve(context.Context, *Challenge) (string, error)
+}
+
+type ApproverFunc func(context.Context, *Challenge) (string, error)
+
+func (f ApproverFunc) Approve(ctx context.Context, c *Challenge) (string, error) {
+	return f(ctx, c)
+}
+
+func FromResponse(resp *http.Response) (*Challenge, error) {
+	value := resp.Header.Get(HeaderAuthenticationInfo)
+	if len(value) == 0 {
+		return nil, ErrNoChallenge
+	}
+
+	var c Challenge
+	if err := json.Unmarshal([]byte(value), &c); err != nil {
+		return nil, errors.New("failed to parse authentication-info")
+	}
+
+	return &c, nil
+}
+
+func (c *Challenge) Push() bool {
+	return c.Type == TypePushTan
+}
+
+// Header returns the x-once-authentication-info value answering the challenge.
+func (c *Challenge) Header(tan string) string {
+	ai, _ := json.Marshal(authenticationInfo{
+		Id:  c.Id,
+		Tan: tan,
+	})
+
+	return string(ai)
+}
@@ -3,7 +3,6 @@ package utils
 import (
 	"context"
 	"crypto/rand"
-	"io"
 	"math/big"
 	"os"
 	"os/signal"
@@ -23,11 +22,6 @@ func RandString(n int) string {
 	return string(ret)
 }
 
-func ReadAllString(r io.Reader) string {
-	d, _ := io.ReadAll(r)
-	return string(d)
-}
-
 func SigWatch(end context.CancelFunc, wait time.Duration, sigs ...os.Signal) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, sigs...)
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kaedwen/trade/pkg/app"
+	"github.com/kaedwen/trade/pkg/app/control"
+	"github.com/kaedwen/trade/pkg/app/instance"
+	"github.com/kaedwen/trade/pkg/app/tan"
+)
+
+var (
+	ErrUsage     = errors.New("usage: trade [-attach] <command> [arguments]")
+	ErrNotLocked = errors.New("password login is not locked")
+)
+
+// attach makes commands wait for the session of a running daemon and fail
+// instead of logging in on their own when none is running.
+var attach bool
+
+// stdin is shared by prompts and the TAN approver so no input is lost in
+// separate buffers.
+var stdin = bufio.NewReader(os.Stdin)
+
+type command func(context.Context, []string) error
+
+var commands = map[string]command{
+	"overview":  runOverview,
+	"orders":    runOrders,
+	"documents": runDocuments,
+	"history":   runHistory,
+	"ctl":       runCtl,
+}
+
+// Run executes a one-shot command given on the command line.
+func Run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("trade", flag.ContinueOnError)
+	fs.BoolVar(&attach, "attach", false, "only use the session of the running daemon, waiting for it, never log in")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	return cmd(ctx, args[1:])
+}
+
+func subcommand(ctx context.Context, args []string, sub map[string]command) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	cmd, ok := sub[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown subcommand %q", args[0])
+	}
+
+	return cmd(ctx, args[1:])
+}
+
+// login creates an application asking for TAN approval on the terminal. The
+// session of a running daemon is used when available, otherwise it logs in
+// on its own. Orders still need a TAN answered here in both cases. The
+// application has to be closed.
+func login(ctx context.Context) (context.Context, *app.Application, error) {
+	a, err := app.NewApplication(app.WithApprover(tan.NewPromptApprover(stdin, os.Stderr)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actx, err := a.Attach(ctx, attach)
+	if err == nil {
+		return actx, a, nil
+	}
+	if attach || !errors.Is(err, control.ErrNotRunning) {
+		return nil, nil, err
+	}
+
+	ctx, err = a.Login(ctx)
+	if err != nil {
+		a.Close()
+	}
+	if errors.Is(err, instance.ErrHeld) {
+		return nil, nil, fmt.Errorf("%w - wait for it to finish or use `trade -attach` with a running daemon", err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ctx, a, nil
+}
+
+func parse(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() != positional {
+		fs.Usage()
+		return nil, ErrUsage
+	}
+
+	return fs.Args(), nil
+}
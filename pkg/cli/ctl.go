@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app"
+	"github.com/kaedwen/trade/pkg/app/control"
+	"github.com/kaedwen/trade/pkg/app/lockout"
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/app/tan"
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+// ctlCommands take the number of arguments given, all are passed to the
+// daemon unchanged.
+var ctlCommands = map[string]int{
+	"approve":      0,
+	"tan":          1,
+	"reauth":       0,
+	"fetch":        1,
+	"pause":        1,
+	"resume":       1,
+	"reload":       0,
+	"unlock-login": 0,
+}
+
+// runCtl talks to the running daemon over its control socket.
+func runCtl(ctx context.Context, args []string) error {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("ctl", flag.ContinueOnError)
+	socket := fs.String("socket", cfg.Control.Socket, "control socket of the daemon")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of the call")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	c, err := control.Dial(ctx, *socket)
+	if errors.Is(err, control.ErrNotRunning) && args[0] == "unlock-login" {
+		return unlockLogin(cfg)
+	}
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if args[0] == "status" {
+		var status app.Status
+		if err := c.Call(ctx, &status, "status"); err != nil {
+			return err
+		}
+
+		return printStatus(&status)
+	}
+
+	n, ok := ctlCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown ctl command %q", args[0])
+	}
+
+	if len(args)-1 != n {
+		return ErrUsage
+	}
+
+	if err := c.Call(ctx, nil, args[0], args[1:]...); err != nil {
+		return err
+	}
+
+	fmt.Println("ok")
+	return nil
+}
+
+// unlockLogin clears the lock in the store when no daemon is running. It
+// fails when the store holds no lock, e.g. when it resolved to another
+// directory than the one of the service.
+func unlockLogin(cfg *config.Config) error {
+	if _, err := os.Stat(cfg.Store.Directory); err != nil {
+		return fmt.Errorf("failed to open store %s - %w", cfg.Store.Directory, err)
+	}
+
+	st, err := store.Open(cfg.Store.Directory)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	guard := lockout.NewGuard(st, nil)
+
+	state, err := guard.State()
+	if err != nil {
+		return err
+	}
+
+	if !state.Locked {
+		return fmt.Errorf("%w in %s, check store.directory", ErrNotLocked, cfg.Store.Directory)
+	}
+
+	if err := guard.Unlock(); err != nil {
+		return err
+	}
+
+	fmt.Println("ok")
+	return nil
+}
+
+func printStatus(s *app.Status) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	state := string(s.Session.State)
+	if c := s.Session.PendingTan; c != nil {
+		switch {
+		case c.Type == tan.TypeMobileTan && len(c.Challenge) > 0:
+			state += fmt.Sprintf(" (%s challenge %s sent to %s)", c.Type, c.Id, c.Challenge)
+		case c.Type == tan.TypePhotoTan:
+			state += fmt.Sprintf(" (%s challenge %s, image on the dashboard)", c.Type, c.Id)
+		default:
+			state += fmt.Sprintf(" (%s challenge %s)", c.Type, c.Id)
+		}
+	}
+	if s.Session.Reused {
+		state += " (reused without TAN)"
+	}
+	if s.Session.Reauth {
+		state += " (re-authenticating)"
+	}
+
+	fmt.Fprintf(tw, "session:\t%s\n", state)
+	if s.Login != nil && s.Login.Locked {
+		fmt.Fprintf(tw, "login:\tlocked since %s - %s\n", formatTime(s.Login.LockedAt), s.Login.LastError)
+	}
+	fmt.Fprintf(tw, "token expiry:\t%s\n", formatTime(s.TokenExpiry))
+	fmt.Fprintf(tw, "snapshot:\t%s\n", formatTime(s.SnapshotAt))
+	fmt.Fprintf(tw, "documents:\t%s\n", formatTime(s.DocumentsAt))
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "JOB\tINTERVAL\tPAUSED\tHELD\tLAST SUCCESS\tLAST ERROR")
+	for _, j := range s.Jobs {
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%s\t%s\n", j.Name, j.Interval, j.Paused, j.Held, formatTime(j.LastSuccess), j.LastError)
+	}
+
+	return tw.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Local().Format(time.DateTime)
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+func runDocuments(ctx context.Context, args []string) error {
+	return subcommand(ctx, args, map[string]command{
+		"list": runDocumentsList,
+		"get":  runDocumentsGet,
+	})
+}
+
+func runDocumentsList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("documents list", flag.ContinueOnError)
+	unread := fs.Bool("unread", false, "only show unread documents")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	documents, err := a.Postbox().Documents(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DOCUMENT\tDATE\tTYPE\tREAD\tADVERTISEMENT\tNAME")
+	for _, d := range documents {
+		if *unread && d.DocumentMetaData.AlreadyRead {
+			continue
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\t%v\t%s\n", d.DocumentId, d.DateCreation, d.MimeType, d.DocumentMetaData.AlreadyRead, d.Advertisement, d.Name)
+	}
+
+	return tw.Flush()
+}
+
+func runDocumentsGet(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("documents get", flag.ContinueOnError)
+	output := fs.String("o", "", "output file (default stdout)")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	documents, err := a.Postbox().Documents(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range documents {
+		if d.DocumentId != pos[0] {
+			continue
+		}
+
+		var w io.Writer = os.Stdout
+		if len(*output) > 0 {
+			f, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		return a.Postbox().Download(ctx, &d, w)
+	}
+
+	return fmt.Errorf("document %s not found", pos[0])
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/store"
+	"github.com/kaedwen/trade/pkg/config"
+)
+
+// runHistory queries the local store, it needs no login.
+func runHistory(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	series := fs.String("series", string(store.SeriesBalances), "series (balances, depots, positions, orders)")
+	key := fs.String("key", "", "account, depot, depot/instrument or order id, glob patterns allowed")
+	from := fs.String("from", "", "start of range (YYYY-MM-DD or RFC3339)")
+	to := fs.String("to", "", "end of range (YYYY-MM-DD or RFC3339)")
+	resolution := fs.Duration("resolution", 0, "downsample to one value per interval, e.g. 24h")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	q := store.Query{Series: store.Series(*series), Key: *key, Resolution: *resolution}
+
+	var err error
+	if q.From, err = parseTime(*from); err != nil {
+		return err
+	}
+	if q.To, err = parseTime(*to); err != nil {
+		return err
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return err
+	}
+
+	st, err := store.Open(cfg.Store.Directory)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	if q.Series == store.SeriesOrders {
+		records, err := st.Records(q)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(tw, "TIME\tORDER\tEVENT")
+		for _, r := range records {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Time.Format(time.RFC3339), r.Key, r.Data)
+		}
+
+		return tw.Flush()
+	}
+
+	samples, err := st.Query(q)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(tw, "TIME\tKEY\tVALUES")
+	for _, s := range samples {
+		if s.Removed() {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Time.Format(time.RFC3339), s.Key, "removed")
+			continue
+		}
+
+		var values []string
+		for _, k := range slices.Sorted(maps.Keys(s.Values)) {
+			values = append(values, fmt.Sprintf("%s=%v", k, s.Values[k]))
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Time.Format(time.RFC3339), s.Key, strings.Join(values, " "))
+	}
+
+	return tw.Flush()
+}
+
+func parseTime(v string) (time.Time, error) {
+	if len(v) == 0 {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation(time.DateOnly, v, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, v)
+}
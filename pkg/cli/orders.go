@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kaedwen/trade/pkg/app/brokerage"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+const orderHeader = "ORDER\tDEPOT\tSTATUS\tSIDE\tTYPE\tINSTRUMENT\tQUANTITY\tEXECUTED\tAVG PRICE\tLIMIT\tVALIDITY"
+
+func runOrders(ctx context.Context, args []string) error {
+	return subcommand(ctx, args, map[string]command{
+		"list":       runOrdersList,
+		"show":       runOrdersShow,
+		"change":     runOrdersChange,
+		"place":      runOrdersPlace,
+		"quote":      runOrdersQuote,
+		"cancel":     runOrdersCancel,
+		"dimensions": runOrdersDimensions,
+	})
+}
+
+func runOrdersList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("orders list", flag.ContinueOnError)
+	depot := fs.String("depot", "", "depot id (default all depots)")
+	state := fs.String("state", "", "filter by order status, e.g. OPEN")
+	side := fs.String("side", "", "filter by side (BUY, SELL)")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	depotIds := []string{*depot}
+	if len(*depot) == 0 {
+		depots, err := a.Brokerage().Depots(ctx)
+		if err != nil {
+			return err
+		}
+
+		depotIds = depotIds[:0]
+		for _, d := range depots {
+			depotIds = append(depotIds, d.DepotId)
+		}
+	}
+
+	filter := brokerage.OrderFilter{
+		Status: model.OrderStatus(strings.ToUpper(*state)),
+		Side:   model.OrderSide(strings.ToUpper(*side)),
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, orderHeader)
+
+	for _, id := range depotIds {
+		orders, err := a.Brokerage().Orders(ctx, id, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, o := range orders {
+			printOrderRow(tw, &o, "")
+		}
+	}
+
+	return tw.Flush()
+}
+
+func runOrdersShow(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("orders show", flag.ContinueOnError)
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	order, err := a.Brokerage().Order(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+
+	printOrder(order)
+
+	return nil
+}
+
+func runOrdersChange(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("orders change", flag.ContinueOnError)
+	limit := fs.Float64("limit", 0, "new limit")
+	stop := fs.Float64("stop", 0, "new stop (trigger) limit")
+	validityType := fs.String("validity-type", "", "new validity type (GFD, GTD, GTC)")
+	validity := fs.String("validity", "", "new validity date (YYYY-MM-DD) for GTD")
+	currency := fs.String("currency", "EUR", "currency of limit and stop")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	change := model.OrderChange{
+		OrderId:      pos[0],
+		ValidityType: model.ValidityType(strings.ToUpper(*validityType)),
+		Validity:     *validity,
+	}
+	if *limit > 0 {
+		change.Limit = &model.Amount{Value: *limit, Unit: *currency}
+	}
+	if *stop > 0 {
+		change.TriggerLimit = &model.Amount{Value: *stop, Unit: *currency}
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	current, err := a.Brokerage().Order(ctx, change.OrderId)
+	if err != nil {
+		return err
+	}
+
+	printOrder(current)
+
+	data, _ := json.MarshalIndent(change, "", "  ")
+	fmt.Println(string(data))
+
+	if ok, err := confirm("change order?"); err != nil {
+		return err
+	} else if !ok {
+		return ErrAborted
+	}
+
+	order, err := a.Brokerage().ChangeOrder(ctx, change, a.Approver())
+	if err != nil {
+		return err
+	}
+
+	printOrder(order)
+
+	return nil
+}
+
+func runOrdersCancel(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("orders cancel", flag.ContinueOnError)
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	current, err := a.Brokerage().Order(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+
+	printOrder(current)
+
+	if ok, err := confirm("cancel order?"); err != nil {
+		return err
+	} else if !ok {
+		return ErrAborted
+	}
+
+	order, err := a.Brokerage().CancelOrder(ctx, pos[0], a.Approver())
+	if err != nil {
+		return err
+	}
+
+	printOrder(order)
+
+	return nil
+}
+
+func runOrdersDimensions(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("orders dimensions", flag.ContinueOnError)
+	venue := fs.String("venue", "", "only show the given venue id")
+	pos, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	d, err := a.Brokerage().Dimensions(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s %s\n", d.InstrumentId, d.Wkn, d.Isin)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VENUE\tNAME\tTYPE\tORDER TYPE\tVALIDITY\tLIMIT EXTENSIONS")
+	for _, v := range d.Venues {
+		if len(*venue) > 0 && v.VenueId != *venue {
+			continue
+		}
+
+		for _, t := range slices.Sorted(maps.Keys(v.OrderTypes)) {
+			ot := v.OrderTypes[t]
+
+			validityTypes := ot.ValidityTypes
+			if len(validityTypes) == 0 {
+				validityTypes = v.ValidityTypes
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%v\t%v\n", v.VenueId, v.Name, v.Type, t, validityTypes, ot.LimitExtensions)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func printOrder(o *model.Order) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, orderHeader)
+	printOrderRow(tw, o, "")
+	tw.Flush()
+
+	for _, so := range append([]model.Order{*o}, o.SubOrders...) {
+		for _, e := range so.Executions {
+			fmt.Printf("  %s execution %d: %v @ %v %s (%s)\n", so.OrderId, e.ExecutionNumber, e.ExecutionQuantity.Value, e.ExecutionPrice.Value, e.ExecutionPrice.Unit, e.ExecutionTimestamp)
+		}
+	}
+}
+
+// printOrderRow prints the order followed by the legs of combination orders.
+func printOrderRow(tw *tabwriter.Writer, o *model.Order, indent string) {
+	avg := "-"
+	if p, ok := o.AveragePrice(); ok {
+		avg = fmt.Sprintf("%.4f %s", p.Value, p.Unit)
+	}
+
+	fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\t%s\t%s\t%v\t%v\t%s\t%s\t%s %s\n",
+		indent, o.OrderId, o.DepotId, o.OrderStatus, o.Side, o.OrderType, o.InstrumentId,
+		o.Quantity.Value, o.Executed(), avg, amount(o.Limit), o.ValidityType, o.Validity)
+
+	for _, so := range o.SubOrders {
+		printOrderRow(tw, &so, indent+"└ ")
+	}
+}
+
+func amount(a *model.Amount) string {
+	if a == nil {
+		return "-"
+	}
+
+	return fmt.Sprintf("%v %s", a.Value, a.Unit)
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+func runOverview(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("overview", flag.ContinueOnError)
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	data, err := a.Banking().AllBalances(ctx)
+	if err != nil {
+		return err
+	}
+
+	nw, err := data.NetWorth()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "PRODUCT\tTYPE\tCOUNT\tVALUE\t")
+	for _, g := range nw.Groups {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f %s\t\n", g.ProductType, g.Text, g.Count, g.Value, nw.Unit)
+	}
+	fmt.Fprintf(tw, "NET WORTH\t\t\t%.2f %s\t\n", nw.Total, nw.Unit)
+
+	return tw.Flush()
+}
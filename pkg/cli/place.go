@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+var (
+	ErrAborted  = errors.New("aborted")
+	ErrLeg2Side = errors.New("-leg2-side is required for combined orders")
+)
+
+type orderFlags struct {
+	side           *string
+	orderType      *string
+	venue          *string
+	quantity       *float64
+	limit          *float64
+	stop           *float64
+	limitExtension *string
+	validityType   *string
+	validity       *string
+}
+
+func newOrderFlags(fs *flag.FlagSet, prefix string) *orderFlags {
+	return &orderFlags{
+		side:           fs.String(prefix+"side", "", "order side (BUY, SELL)"),
+		orderType:      fs.String(prefix+"type", "", "order type, e.g. LIMIT, STOP_MARKET"),
+		venue:          fs.String(prefix+"venue", "", "venue id"),
+		quantity:       fs.Float64(prefix+"quantity", 0, "quantity"),
+		limit:          fs.Float64(prefix+"limit", 0, "limit"),
+		stop:           fs.Float64(prefix+"stop", 0, "stop (trigger) limit"),
+		limitExtension: fs.String(prefix+"limit-extension", "", "limit extension, e.g. FOK, IOC"),
+		validityType:   fs.String(prefix+"validity-type", "", "validity type (GFD, GTD, GTC)"),
+		validity:       fs.String(prefix+"validity", "", "validity date (YYYY-MM-DD) for GTD"),
+	}
+}
+
+// request builds the leg, taking unset attributes from the given parent leg.
+// The side is never taken, it differs between the legs of most combinations.
+func (of *orderFlags) request(depot, instrument, currency string, parent *model.OrderRequest) model.OrderRequest {
+	r := model.OrderRequest{
+		DepotId:        depot,
+		OrderType:      model.OrderType(strings.ToUpper(*of.orderType)),
+		Side:           model.OrderSide(strings.ToUpper(*of.side)),
+		InstrumentId:   instrument,
+		VenueId:        *of.venue,
+		LimitExtension: strings.ToUpper(*of.limitExtension),
+		ValidityType:   model.ValidityType(strings.ToUpper(*of.validityType)),
+		Validity:       *of.validity,
+	}
+
+	if *of.quantity > 0 {
+		r.Quantity = &model.Amount{Value: *of.quantity, Unit: "XXX"}
+	}
+	if *of.limit > 0 {
+		r.Limit = &model.Amount{Value: *of.limit, Unit: currency}
+	}
+	if *of.stop > 0 {
+		r.TriggerLimit = &model.Amount{Value: *of.stop, Unit: currency}
+	}
+
+	if parent != nil {
+		if len(r.VenueId) == 0 {
+			r.VenueId = parent.VenueId
+		}
+		if r.Quantity == nil {
+			r.Quantity = parent.Quantity
+		}
+		if len(r.ValidityType) == 0 {
+			r.ValidityType, r.Validity = parent.ValidityType, parent.Validity
+		}
+	}
+
+	return r
+}
+
+func runOrdersPlace(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("orders place", flag.ContinueOnError)
+	depot := fs.String("depot", "", "depot id")
+	instrument := fs.String("instrument", "", "instrument id")
+	currency := fs.String("currency", "EUR", "currency of limits")
+	combination := fs.String("combination", "", "combine with a second leg (OCO, NEXT)")
+	leg := newOrderFlags(fs, "")
+	leg2 := newOrderFlags(fs, "leg2-")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	if len(*depot) == 0 || len(*instrument) == 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	order := leg.request(*depot, *instrument, *currency, nil)
+
+	if len(*combination) > 0 && len(*leg2.side) == 0 {
+		return ErrLeg2Side
+	}
+
+	switch strings.ToUpper(*combination) {
+	case "":
+	case "OCO":
+		second := leg2.request(*depot, *instrument, *currency, &order)
+		order = model.OrderRequest{DepotId: *depot, OrderType: model.OrderTypeOneCancelsOther, SubOrders: []model.OrderRequest{order, second}}
+	case "NEXT":
+		second := leg2.request(*depot, *instrument, *currency, &order)
+		order = model.OrderRequest{DepotId: *depot, OrderType: model.OrderTypeNextOrder, SubOrders: []model.OrderRequest{order, second}}
+	default:
+		return fmt.Errorf("unknown combination %q", *combination)
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if err := a.Brokerage().ValidateOrder(ctx, &order); err != nil {
+		return err
+	}
+
+	data, _ := json.MarshalIndent(order, "", "  ")
+	fmt.Println(string(data))
+
+	if ok, err := confirm("place order?"); err != nil {
+		return err
+	} else if !ok {
+		return ErrAborted
+	}
+
+	placed, err := a.Brokerage().PlaceOrder(ctx, order, a.Approver())
+	if err != nil {
+		return err
+	}
+
+	printOrder(placed)
+
+	return nil
+}
+
+func confirm(prompt string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+
+	line, err := stdin.ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(strings.TrimSpace(line), "y"), nil
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kaedwen/trade/pkg/app/brokerage"
+	"github.com/kaedwen/trade/pkg/model"
+)
+
+func runOrdersQuote(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("orders quote", flag.ContinueOnError)
+	depot := fs.String("depot", "", "depot id")
+	instrument := fs.String("instrument", "", "instrument id")
+	venue := fs.String("venue", "", "venue id of the issuer")
+	side := fs.String("side", "", "order side (BUY, SELL)")
+	quantity := fs.Float64("quantity", 0, "quantity")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	if len(*depot) == 0 || len(*instrument) == 0 || len(*venue) == 0 || len(*side) == 0 || *quantity <= 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	ctx, a, err := login(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	ticket, err := a.Brokerage().QuoteTicket(ctx, *depot, a.Approver())
+	if err != nil {
+		return err
+	}
+
+	quote, err := a.Brokerage().Quote(ctx, ticket, model.QuoteRequest{
+		InstrumentId: *instrument,
+		VenueId:      *venue,
+		Side:         model.OrderSide(strings.ToUpper(*side)),
+		Quantity:     model.Amount{Value: *quantity, Unit: "XXX"},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("quote %s for %v x %s: bid %s ask %s\n", quote.QuoteId, quote.Quantity.Value, quote.InstrumentId, amount(quote.Bid), amount(quote.Ask))
+
+	if ok, err := confirmQuote(quote); err != nil {
+		return err
+	} else if !ok {
+		return ErrAborted
+	}
+
+	order, err := a.Brokerage().PlaceQuoteOrder(ctx, ticket, quote)
+	if err != nil {
+		return err
+	}
+
+	printOrder(order)
+
+	return nil
+}
+
+// confirmQuote asks for confirmation while counting down the time the quote
+// remains binding.
+func confirmQuote(quote *model.Quote) (bool, error) {
+	type answer struct {
+		line string
+		err  error
+	}
+
+	answers := make(chan answer, 1)
+	go func() {
+		line, err := stdin.ReadString('\n')
+		answers <- answer{line, err}
+	}()
+
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	for {
+		left := time.Until(quote.Expiry).Round(time.Second)
+		if left <= 0 {
+			fmt.Fprintln(os.Stderr)
+			return false, brokerage.ErrQuoteExpired
+		}
+
+		fmt.Fprintf(os.Stderr, "\r%s %v %s at %v %s, confirm within %v [y/N] ", quote.Side, quote.Quantity.Value, quote.InstrumentId, quote.Limit.Value, quote.Limit.Unit, left)
+
+		select {
+		case a := <-answers:
+			if a.err != nil {
+				return false, a.err
+			}
+			if quote.Expired() {
+				return false, brokerage.ErrQuoteExpired
+			}
+			return strings.EqualFold(strings.TrimSpace(a.line), "y"), nil
+		case <-t.C:
+		}
+	}
+}
@@ -2,23 +2,155 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
-var ErrNoConfigAvailable = errors.New("no config available")
+var (
+	ErrNoConfigAvailable = errors.New("no config available")
+	ErrInvalidInterval   = errors.New("interval must be positive")
+)
 
 type Config struct {
-	ApiAddress   *URL   `yaml:"apiAddress"`
-	TokenAddress *URL   `yaml:"tokenAddress"`
-	ClientId     string `yaml:"clientId"`
-	ClientSecret string `yaml:"clientSecret"`
-	AccountId    string `yaml:"accountId"`
-	Pin          string `yaml:"pin"`
+	ApiAddress    *URL          `yaml:"apiAddress"`
+	TokenAddress  *URL          `yaml:"tokenAddress"`
+	ClientId      string        `yaml:"clientId"`
+	ClientSecret  string        `yaml:"clientSecret"`
+	AccountId     string        `yaml:"accountId"`
+	Pin           string        `yaml:"pin"`
+	Http          Http          `yaml:"http"`
+	Jobs          Jobs          `yaml:"jobs"`
+	Dimensions    Dimensions    `yaml:"dimensions"`
+	Postbox       Postbox       `yaml:"postbox"`
+	Store         Store         `yaml:"store"`
+	Events        Events        `yaml:"events"`
+	Alerts        []Alert       `yaml:"alerts"`
+	Notifications Notifications `yaml:"notifications"`
+	Control       Control       `yaml:"control"`
+	Log           Log           `yaml:"log"`
+}
+
+// Log configures the level (debug, info, warn, error) and the format (text or
+// json) of the log output. Trace logs the timing of every API request, Dump
+// additionally its redacted headers and bodies.
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+	Trace  bool   `yaml:"trace"`
+	Dump   bool   `yaml:"dump"`
+}
+
+type Control struct {
+	Socket string `yaml:"socket"`
+}
+
+type Notifications struct {
+	Dedup         Duration  `yaml:"dedup"`
+	RetryInterval Duration  `yaml:"retryInterval"`
+	RetryMaxAge   Duration  `yaml:"retryMaxAge"`
+	Channels      []Channel `yaml:"channels"`
+}
+
+// Channel is a notification target. Kinds are webhook, ntfy, gotify, email
+// and command.
+type Channel struct {
+	Name        string     `yaml:"name"`
+	Kind        string     `yaml:"kind"`
+	URL         string     `yaml:"url"`
+	Token       string     `yaml:"token"`
+	Secret      string     `yaml:"secret"`
+	Command     []string   `yaml:"command"`
+	SMTP        SMTP       `yaml:"smtp"`
+	MinSeverity string     `yaml:"minSeverity"`
+	Types       []string   `yaml:"types"`
+	QuietHours  QuietHours `yaml:"quietHours"`
+}
+
+type SMTP struct {
+	Address  string   `yaml:"address"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// QuietHours is a daily time range (15:04) in local time.
+type QuietHours struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// Alert is a rule evaluated after every fetch. Kinds are balanceBelow,
+// creditUtilisationAbove, positionDrop, incomingTransactionAbove and
+// transactionMatch.
+type Alert struct {
+	Name       string   `yaml:"name"`
+	Kind       string   `yaml:"kind"`
+	Account    string   `yaml:"account"`
+	Instrument string   `yaml:"instrument"`
+	Threshold  float64  `yaml:"threshold"`
+	Hysteresis float64  `yaml:"hysteresis"`
+	Pattern    string   `yaml:"pattern"`
+	Cooldown   Duration `yaml:"cooldown"`
+	Severity   string   `yaml:"severity"`
+}
+
+type Events struct {
+	// PriceThreshold is the relative price move in percent reported as event.
+	PriceThreshold float64 `yaml:"priceThreshold"`
+	// Transactions is the number of latest transactions compared per account.
+	Transactions int `yaml:"transactions"`
+}
+
+type Store struct {
+	Directory string `yaml:"directory"`
+}
+
+type Postbox struct {
+	Directory      string `yaml:"directory"`
+	Template       string `yaml:"template"`
+	Advertisements bool   `yaml:"advertisements"`
+}
+
+type Dimensions struct {
+	TTL Duration `yaml:"ttl"`
+}
+
+type Jobs struct {
+	Accounts  Duration `yaml:"accounts"`
+	Orders    Duration `yaml:"orders"`
+	Documents Duration `yaml:"documents"`
+}
+
+// Http configures the local API. Address is a TCP address or a Unix socket
+// path prefixed with "unix:". Token enables bearer authentication. Write
+// enables the endpoints changing the session or orders.
+type Http struct {
+	Address string `yaml:"address"`
+	Token   string `yaml:"token"`
+	Write   bool   `yaml:"write"`
+	TLS     TLS    `yaml:"tls"`
+	Metrics bool   `yaml:"metrics"`
+	Ready   Ready  `yaml:"ready"`
+}
+
+// Ready configures the checks of /readyz. MaxJobAge of zero allows three
+// intervals of each job since its last success.
+type Ready struct {
+	TokenMargin Duration `yaml:"tokenMargin"`
+	MaxJobAge   Duration `yaml:"maxJobAge"`
+}
+
+type TLS struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
 }
 
 func NewConfig() (*Config, error) {
@@ -48,10 +180,107 @@ func newConfigFromFile(f string) (*Config, error) {
 		return nil, err
 	}
 
-	var cfg Config
+	cfg := defaultConfig()
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
 
+	if err := cfg.loadCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// validate rejects intervals a ticker cannot run with.
+func (c *Config) validate() error {
+	intervals := []struct {
+		name string
+		d    Duration
+	}{
+		{"jobs.accounts", c.Jobs.Accounts},
+		{"jobs.orders", c.Jobs.Orders},
+		{"jobs.documents", c.Jobs.Documents},
+		{"notifications.retryInterval", c.Notifications.RetryInterval},
+	}
+
+	for _, i := range intervals {
+		if i.d.Duration <= 0 {
+			return fmt.Errorf("invalid %s - %w", i.name, ErrInvalidInterval)
+		}
+	}
+
+	return nil
+}
+
+func defaultConfig() Config {
+	return Config{
+		Jobs: Jobs{
+			Accounts:  NewDuration(time.Minute),
+			Orders:    NewDuration(30 * time.Second),
+			Documents: NewDuration(time.Hour),
+		},
+		Dimensions: Dimensions{
+			TTL: NewDuration(24 * time.Hour),
+		},
+		Postbox: Postbox{
+			Template: "{{.Date}}_{{.Category}}_{{.Name}}{{.Ext}}",
+		},
+		Http: Http{
+			Ready: Ready{
+				TokenMargin: NewDuration(time.Minute),
+			},
+		},
+		Store: Store{
+			Directory: defaultStateDirectory(),
+		},
+		Events: Events{
+			PriceThreshold: 1,
+			Transactions:   20,
+		},
+		Notifications: Notifications{
+			Dedup:         NewDuration(time.Hour),
+			RetryInterval: NewDuration(time.Minute),
+			RetryMaxAge:   NewDuration(24 * time.Hour),
+		},
+		Control: Control{
+			Socket: defaultControlSocket(),
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "text",
+		},
+	}
+}
+
+// defaultControlSocket prefers the directory systemd sets up for
+// RuntimeDirectory= over the user's runtime directory.
+func defaultControlSocket() string {
+	if d, _, _ := strings.Cut(os.Getenv("RUNTIME_DIRECTORY"), ":"); len(d) > 0 {
+		return filepath.Join(d, "control.sock")
+	}
+
+	if d := os.Getenv("XDG_RUNTIME_DIR"); len(d) > 0 {
+		return filepath.Join(d, "trade", "control.sock")
+	}
+
+	return filepath.Join(defaultStateDirectory(), "control.sock")
+}
+
+// defaultStateDirectory prefers the directory systemd sets up for
+// StateDirectory= over the user's state directory.
+func defaultStateDirectory() string {
+	if d, _, _ := strings.Cut(os.Getenv("STATE_DIRECTORY"), ":"); len(d) > 0 {
+		return d
+	}
+
+	if h, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(h, ".local", "state", "trade")
+	}
+
+	return "state"
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadCredentials fills secrets missing in the config file from the
+// credentials systemd passes with LoadCredential= or SetCredential=.
+func (cfg *Config) loadCredentials() error {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if len(dir) == 0 {
+		return nil
+	}
+
+	for name, v := range map[string]*string{
+		"pin":          &cfg.Pin,
+		"clientSecret": &cfg.ClientSecret,
+		"httpToken":    &cfg.Http.Token,
+	} {
+		if len(*v) > 0 {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		*v = strings.TrimSpace(string(data))
+	}
+
+	return nil
+}
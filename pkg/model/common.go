@@ -0,0 +1,11 @@
+package model
+
+type Paging struct {
+	Index   int `json:"index"`
+	Matches int `json:"matches"`
+}
+
+type Amount struct {
+	Value float64 `json:"value,string"`
+	Unit  string  `json:"unit"`
+}
@@ -0,0 +1,14 @@
+package model
+
+type DepotsResponse struct {
+	Paging Paging  `json:"paging"`
+	Values []Depot `json:"values"`
+}
+
+type Depot struct {
+	DepotId                    string   `json:"depotId"`
+	DepotDisplayId             string   `json:"depotDisplayId"`
+	ClientId                   string   `json:"clientId"`
+	DefaultSettlementAccountId string   `json:"defaultSettlementAccountId"`
+	SettlementAccountIds       []string `json:"settlementAccountIds"`
+}
@@ -0,0 +1,41 @@
+package model
+
+type DimensionsResponse struct {
+	Paging Paging                `json:"paging"`
+	Values []InstrumentDimension `json:"values"`
+}
+
+type InstrumentDimension struct {
+	InstrumentId string           `json:"instrumentId"`
+	Wkn          string           `json:"wkn"`
+	Isin         string           `json:"isin"`
+	Mnemonic     string           `json:"mnemonic"`
+	Venues       []VenueDimension `json:"venues"`
+}
+
+type VenueDimension struct {
+	Name              string                           `json:"name"`
+	VenueId           string                           `json:"venueId"`
+	Type              string                           `json:"type"`
+	Sides             []OrderSide                      `json:"sides,omitempty"`
+	ValidityTypes     []ValidityType                   `json:"validityTypes,omitempty"`
+	OrderTypes        map[OrderType]OrderTypeDimension `json:"orderTypes"`
+	MinimumQuantity   *Amount                          `json:"minimumQuantity,omitempty"`
+	QuantityIncrement *Amount                          `json:"quantityIncrement,omitempty"`
+}
+
+type OrderTypeDimension struct {
+	LimitExtensions     []string       `json:"limitExtensions,omitempty"`
+	TradingRestrictions []string       `json:"tradingRestrictions,omitempty"`
+	ValidityTypes       []ValidityType `json:"validityTypes,omitempty"`
+}
+
+func (d *InstrumentDimension) Venue(venueId string) (*VenueDimension, bool) {
+	for i := range d.Venues {
+		if d.Venues[i].VenueId == venueId {
+			return &d.Venues[i], true
+		}
+	}
+
+	return nil, false
+}
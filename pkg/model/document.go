@@ -0,0 +1,21 @@
+package model
+
+type DocumentsResponse struct {
+	Paging Paging     `json:"paging"`
+	Values []Document `json:"values"`
+}
+
+type Document struct {
+	DocumentId       string `json:"documentId"`
+	Name             string `json:"name"`
+	DateCreation     string `json:"dateCreation"`
+	MimeType         string `json:"mimeType"`
+	Deletable        bool   `json:"deletable"`
+	Advertisement    bool   `json:"advertisement"`
+	DocumentMetaData struct {
+		Archived          bool   `json:"archived"`
+		AlreadyRead       bool   `json:"alreadyRead"`
+		DateRead          string `json:"dateRead,omitempty"`
+		PreDocumentExists bool   `json:"predocumentExists"`
+	} `json:"documentMetaData"`
+}
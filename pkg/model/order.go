@@ -0,0 +1,192 @@
+package model
+
+type OrderStatus string
+
+const (
+	OrderStatusOpen              OrderStatus = "OPEN"
+	OrderStatusPartiallyExecuted OrderStatus = "PARTIALLY_EXECUTED"
+	OrderStatusExecuted          OrderStatus = "EXECUTED"
+	OrderStatusSettled           OrderStatus = "SETTLED"
+	OrderStatusCancelledUser     OrderStatus = "CANCELLED_USER"
+	OrderStatusCancelledSystem   OrderStatus = "CANCELLED_SYSTEM"
+	OrderStatusCancelledTrade    OrderStatus = "CANCELLED_TRADE"
+	OrderStatusExpired           OrderStatus = "EXPIRED"
+)
+
+type OrderType string
+
+const (
+	OrderTypeMarket             OrderType = "MARKET"
+	OrderTypeLimit              OrderType = "LIMIT"
+	OrderTypeQuote              OrderType = "QUOTE"
+	OrderTypeStopMarket         OrderType = "STOP_MARKET"
+	OrderTypeStopLimit          OrderType = "STOP_LIMIT"
+	OrderTypeTrailingStopMarket OrderType = "TRAILING_STOP_MARKET"
+	OrderTypeTrailingStopLimit  OrderType = "TRAILING_STOP_LIMIT"
+	OrderTypeOneCancelsOther    OrderType = "ONE_CANCELS_OTHER"
+	OrderTypeNextOrder          OrderType = "NEXT_ORDER"
+)
+
+type OrderSide string
+
+const (
+	OrderSideBuy  OrderSide = "BUY"
+	OrderSideSell OrderSide = "SELL"
+)
+
+type ValidityType string
+
+const (
+	ValidityTypeGoodForDay     ValidityType = "GFD"
+	ValidityTypeGoodTillDate   ValidityType = "GTD"
+	ValidityTypeGoodTillCancel ValidityType = "GTC"
+)
+
+type OrdersResponse struct {
+	Paging Paging  `json:"paging"`
+	Values []Order `json:"values"`
+}
+
+type Order struct {
+	DepotId              string           `json:"depotId"`
+	OrderId              string           `json:"orderId"`
+	CreationTimestamp    string           `json:"creationTimestamp"`
+	LegNumber            int              `json:"legNumber"`
+	BestEx               bool             `json:"bestEx"`
+	OrderType            OrderType        `json:"orderType"`
+	OrderStatus          OrderStatus      `json:"orderStatus"`
+	SubOrders            []Order          `json:"subOrders,omitempty"`
+	Side                 OrderSide        `json:"side"`
+	InstrumentId         string           `json:"instrumentId"`
+	QuoteId              string           `json:"quoteId,omitempty"`
+	VenueId              string           `json:"venueId"`
+	Quantity             Amount           `json:"quantity"`
+	LimitExtension       string           `json:"limitExtension,omitempty"`
+	TradingTimestamp     string           `json:"tradingTimestamp,omitempty"`
+	Limit                *Amount          `json:"limit,omitempty"`
+	TriggerLimit         *Amount          `json:"triggerLimit,omitempty"`
+	TrailingLimitDistAbs *Amount          `json:"trailingLimitDistAbs,omitempty"`
+	TrailingLimitDistRel *Amount          `json:"trailingLimitDistRel,omitempty"`
+	ValidityType         ValidityType     `json:"validityType"`
+	Validity             string           `json:"validity,omitempty"`
+	OpenQuantity         *Amount          `json:"openQuantity,omitempty"`
+	CancelledQuantity    *Amount          `json:"cancelledQuantity,omitempty"`
+	ExecutedQuantity     *Amount          `json:"executedQuantity,omitempty"`
+	ExpectedValue        *Amount          `json:"expectedValue,omitempty"`
+	Executions           []OrderExecution `json:"executions,omitempty"`
+}
+
+type OrderExecution struct {
+	ExecutionId        string  `json:"executionId"`
+	ExecutionNumber    int     `json:"executionNumber"`
+	ExecutionQuantity  Amount  `json:"executionQuantity"`
+	ExecutionPrice     Amount  `json:"executionPrice"`
+	ExecutionTimestamp string  `json:"executionTimestamp"`
+	ExpectedValue      *Amount `json:"expectedValue,omitempty"`
+}
+
+// OrderRequest describes a new order. Combination orders (OCO, NEXT) carry
+// their legs as sub orders and are placed with a single TAN.
+type OrderRequest struct {
+	DepotId              string         `json:"depotId,omitempty"`
+	OrderType            OrderType      `json:"orderType"`
+	Side                 OrderSide      `json:"side,omitempty"`
+	InstrumentId         string         `json:"instrumentId,omitempty"`
+	QuoteId              string         `json:"quoteId,omitempty"`
+	VenueId              string         `json:"venueId,omitempty"`
+	Quantity             *Amount        `json:"quantity,omitempty"`
+	LimitExtension       string         `json:"limitExtension,omitempty"`
+	Limit                *Amount        `json:"limit,omitempty"`
+	TriggerLimit         *Amount        `json:"triggerLimit,omitempty"`
+	TrailingLimitDistAbs *Amount        `json:"trailingLimitDistAbs,omitempty"`
+	TrailingLimitDistRel *Amount        `json:"trailingLimitDistRel,omitempty"`
+	ValidityType         ValidityType   `json:"validityType,omitempty"`
+	Validity             string         `json:"validity,omitempty"`
+	SubOrders            []OrderRequest `json:"subOrders,omitempty"`
+}
+
+// OrderChange holds the attributes of an open order that may be modified.
+type OrderChange struct {
+	OrderId              string       `json:"orderId"`
+	Limit                *Amount      `json:"limit,omitempty"`
+	TriggerLimit         *Amount      `json:"triggerLimit,omitempty"`
+	TrailingLimitDistAbs *Amount      `json:"trailingLimitDistAbs,omitempty"`
+	TrailingLimitDistRel *Amount      `json:"trailingLimitDistRel,omitempty"`
+	ValidityType         ValidityType `json:"validityType,omitempty"`
+	Validity             string       `json:"validity,omitempty"`
+}
+
+func (t OrderType) Combination() bool {
+	return t == OrderTypeOneCancelsOther || t == OrderTypeNextOrder
+}
+
+func (s OrderStatus) Final() bool {
+	switch s {
+	case OrderStatusOpen, OrderStatusPartiallyExecuted:
+		return false
+	default:
+		return true
+	}
+}
+
+// Executed returns the executed quantity, summing up the executions when the
+// API did not report it.
+func (o *Order) Executed() float64 {
+	if o.ExecutedQuantity != nil {
+		return o.ExecutedQuantity.Value
+	}
+
+	var q float64
+	for _, e := range o.Executions {
+		q += e.ExecutionQuantity.Value
+	}
+
+	return q
+}
+
+func (o *Order) PartiallyExecuted() bool {
+	return o.OrderStatus == OrderStatusPartiallyExecuted || (!o.OrderStatus.Final() && o.Executed() > 0)
+}
+
+// AveragePrice returns the quantity weighted price over all executions.
+func (o *Order) AveragePrice() (Amount, bool) {
+	var q, v float64
+	var unit string
+	for _, e := range o.Executions {
+		q += e.ExecutionQuantity.Value
+		v += e.ExecutionQuantity.Value * e.ExecutionPrice.Value
+		unit = e.ExecutionPrice.Unit
+	}
+
+	if q == 0 {
+		return Amount{}, false
+	}
+
+	return Amount{Value: v / q, Unit: unit}, true
+}
+
+// Request returns the order as a request, e.g. to validate changed attributes.
+func (o *Order) Request() OrderRequest {
+	r := OrderRequest{
+		DepotId:              o.DepotId,
+		OrderType:            o.OrderType,
+		Side:                 o.Side,
+		InstrumentId:         o.InstrumentId,
+		QuoteId:              o.QuoteId,
+		VenueId:              o.VenueId,
+		Quantity:             &o.Quantity,
+		LimitExtension:       o.LimitExtension,
+		Limit:                o.Limit,
+		TriggerLimit:         o.TriggerLimit,
+		TrailingLimitDistAbs: o.TrailingLimitDistAbs,
+		TrailingLimitDistRel: o.TrailingLimitDistRel,
+		ValidityType:         o.ValidityType,
+		Validity:             o.Validity,
+	}
+
+	for _, so := range o.SubOrders {
+		r.SubOrders = append(r.SubOrders, so.Request())
+	}
+
+	return r
+}
@@ -0,0 +1,40 @@
+package model
+
+type PositionsResponse struct {
+	Paging Paging     `json:"paging"`
+	Values []Position `json:"values"`
+}
+
+type Price struct {
+	Price         Amount `json:"price"`
+	PriceDateTime string `json:"priceDateTime"`
+}
+
+type Instrument struct {
+	InstrumentId string `json:"instrumentId"`
+	Wkn          string `json:"wkn"`
+	Isin         string `json:"isin"`
+	Mnemonic     string `json:"mnemonic"`
+	Name         string `json:"name"`
+	ShortName    string `json:"shortName"`
+}
+
+type Position struct {
+	DepotId               string      `json:"depotId"`
+	PositionId            string      `json:"positionId"`
+	Wkn                   string      `json:"wkn"`
+	CustodyType           string      `json:"custodyType"`
+	Quantity              Amount      `json:"quantity"`
+	AvailableQuantity     Amount      `json:"availableQuantity"`
+	CurrentPrice          Price       `json:"currentPrice"`
+	PurchasePrice         Amount      `json:"purchasePrice"`
+	PrevDayPrice          Price       `json:"prevDayPrice"`
+	CurrentValue          Amount      `json:"currentValue"`
+	PurchaseValue         Amount      `json:"purchaseValue"`
+	ProfitLossPurchaseAbs Amount      `json:"profitLossPurchaseAbs"`
+	ProfitLossPurchaseRel float64     `json:"profitLossPurchaseRel,string"`
+	ProfitLossPrevDayAbs  Amount      `json:"profitLossPrevDayAbs"`
+	ProfitLossPrevDayRel  float64     `json:"profitLossPrevDayRel,string"`
+	InstrumentId          string      `json:"instrumentId"`
+	Instrument            *Instrument `json:"instrument,omitempty"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+type QuoteTicketRequest struct {
+	DepotId string `json:"depotId"`
+}
+
+type QuoteTicket struct {
+	QuoteTicketId   string `json:"quoteTicketId"`
+	DepotId         string `json:"depotId"`
+	ExpiryTimestamp string `json:"expiryTimestamp,omitempty"`
+
+	// Expiry is the point in time the ticket stops accepting orders, zero
+	// when the API did not tell.
+	Expiry time.Time `json:"-"`
+
+	// AuthenticationInfo answers the ticket's TAN challenge and authorizes
+	// the quote order.
+	AuthenticationInfo string `json:"-"`
+}
+
+type QuoteRequest struct {
+	QuoteTicketId string    `json:"quoteTicketId"`
+	DepotId       string    `json:"depotId"`
+	InstrumentId  string    `json:"instrumentId"`
+	VenueId       string    `json:"venueId"`
+	Side          OrderSide `json:"side"`
+	Quantity      Amount    `json:"quantity"`
+}
+
+type Quote struct {
+	QuoteId       string    `json:"quoteId"`
+	QuoteTicketId string    `json:"quoteTicketId"`
+	InstrumentId  string    `json:"instrumentId"`
+	VenueId       string    `json:"venueId"`
+	Side          OrderSide `json:"side"`
+	Quantity      Amount    `json:"quantity"`
+	Bid           *Amount   `json:"bid,omitempty"`
+	Ask           *Amount   `json:"ask,omitempty"`
+	Limit         Amount    `json:"limit"`
+
+	CreationTimestamp string `json:"creationTimestamp,omitempty"`
+	ExpiryTimestamp   string `json:"expiryTimestamp,omitempty"`
+
+	// Expiry is the point in time the quote stops being binding.
+	Expiry time.Time `json:"-"`
+}
+
+func (t *QuoteTicket) Expired() bool {
+	return !t.Expiry.IsZero() && time.Now().After(t.Expiry)
+}
+
+func (q *Quote) Expired() bool {
+	return time.Now().After(q.Expiry)
+}
+
+// timestampLayouts are the formats of timestamps in API responses.
+var timestampLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.000-0700", "2006-01-02T15:04:05-0700"}
+
+// ParseTimestamp parses a timestamp of an API response.
+func ParseTimestamp(v string) (time.Time, error) {
+	var err error
+	for _, layout := range timestampLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+type ProductType string
+
+const (
+	ProductTypeAccount ProductType = "ACCOUNT"
+	ProductTypeDepot   ProductType = "DEPOT"
+	ProductTypeCard    ProductType = "CARD"
+)
+
+type AllBalancesResponse struct {
+	Paging Paging          `json:"paging"`
+	Values []ReportBalance `json:"values"`
+}
+
+// ReportBalance is a single product of the balances report. The balance
+// object depends on the product type.
+type ReportBalance struct {
+	ProductId            string          `json:"productId"`
+	ProductType          ProductType     `json:"productType"`
+	TargetClientId       string          `json:"targetClientId"`
+	ClientConnectionType string          `json:"clientConnectionType"`
+	Balance              json.RawMessage `json:"balance"`
+}
+
+type DepotBalance struct {
+	DepotId               string  `json:"depotId"`
+	DateLastUpdate        string  `json:"dateLastUpdate"`
+	PrevDayValue          Amount  `json:"prevDayValue"`
+	CurrentValue          Amount  `json:"currentValue"`
+	PurchaseValue         Amount  `json:"purchaseValue"`
+	ProfitLossPurchaseAbs Amount  `json:"profitLossPurchaseAbs"`
+	ProfitLossPurchaseRel float64 `json:"profitLossPurchaseRel,string"`
+	ProfitLossPrevDayAbs  Amount  `json:"profitLossPrevDayAbs"`
+	ProfitLossPrevDayRel  float64 `json:"profitLossPrevDayRel,string"`
+}
+
+type CardBalance struct {
+	CardId              string `json:"cardId"`
+	Balance             Amount `json:"balance"`
+	AvailableCashAmount Amount `json:"availableCashAmount"`
+}
+
+type NetWorth struct {
+	Total  float64         `json:"total"`
+	Unit   string          `json:"unit"`
+	Groups []NetWorthGroup `json:"groups"`
+}
+
+// NetWorthGroup sums up the products of one type, accounts are grouped by
+// their account type.
+type NetWorthGroup struct {
+	ProductType ProductType `json:"productType"`
+	Key         string      `json:"key"`
+	Text        string      `json:"text"`
+	Count       int         `json:"count"`
+	Value       float64     `json:"value"`
+}
+
+func (rb *ReportBalance) AccountBalance() (*AccountBalance, error) {
+	var b AccountBalance
+	return &b, json.Unmarshal(rb.Balance, &b)
+}
+
+func (rb *ReportBalance) DepotBalance() (*DepotBalance, error) {
+	var b DepotBalance
+	return &b, json.Unmarshal(rb.Balance, &b)
+}
+
+func (rb *ReportBalance) CardBalance() (*CardBalance, error) {
+	var b CardBalance
+	return &b, json.Unmarshal(rb.Balance, &b)
+}
+
+func (r *AllBalancesResponse) NetWorth() (NetWorth, error) {
+	nw := NetWorth{Unit: "EUR"}
+	groups := make(map[string]*NetWorthGroup)
+
+	add := func(pt ProductType, key, text string, value float64) {
+		id := string(pt) + "/" + key
+		g, ok := groups[id]
+		if !ok {
+			g = &NetWorthGroup{ProductType: pt, Key: key, Text: text}
+			groups[id] = g
+		}
+
+		g.Count++
+		g.Value += value
+		nw.Total += value
+	}
+
+	for _, v := range r.Values {
+		switch v.ProductType {
+		case ProductTypeDepot:
+			b, err := v.DepotBalance()
+			if err != nil {
+				return nw, err
+			}
+			add(v.ProductType, string(v.ProductType), "Depot", b.CurrentValue.Value)
+		case ProductTypeCard:
+			b, err := v.CardBalance()
+			if err != nil {
+				return nw, err
+			}
+			add(v.ProductType, string(v.ProductType), "Card", b.Balance.Value)
+		default:
+			b, err := v.AccountBalance()
+			if err != nil {
+				return nw, err
+			}
+			add(v.ProductType, b.Account.AccountType.Key, b.Account.AccountType.Text, b.BalanceEUR.Value)
+		}
+	}
+
+	for _, g := range groups {
+		nw.Groups = append(nw.Groups, *g)
+	}
+
+	slices.SortFunc(nw.Groups, func(a, b NetWorthGroup) int {
+		return strings.Compare(string(a.ProductType)+a.Key, string(b.ProductType)+b.Key)
+	})
+
+	return nw, nil
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func balance(productType ProductType, balance string) ReportBalance {
+	return ReportBalance{ProductId: "p", ProductType: productType, Balance: []byte(balance)}
+}
+
+func account(key, text, value string) ReportBalance {
+	return balance(ProductTypeAccount, fmt.Sprintf(`{"account":{"accountType":{"key":%q,"text":%q}},"balanceEUR":{"value":%q,"unit":"EUR"}}`, key, text, value))
+}
+
+func TestNetWorth(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []ReportBalance
+		want    NetWorth
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			want: NetWorth{Unit: "EUR"},
+		},
+		{
+			name: "groups by product and account type",
+			values: []ReportBalance{
+				balance(ProductTypeDepot, `{"currentValue":{"value":"1000.5","unit":"EUR"}}`),
+				account("CA", "Girokonto", "250.25"),
+				account("CA", "Girokonto", "-50"),
+				account("TG", "Tagesgeld PLUS-Konto", "500"),
+				balance(ProductTypeCard, `{"balance":{"value":"-20.75","unit":"EUR"}}`),
+			},
+			want: NetWorth{
+				Total: 1680,
+				Unit:  "EUR",
+				Groups: []NetWorthGroup{
+					{ProductType: ProductTypeAccount, Key: "CA", Text: "Girokonto", Count: 2, Value: 200.25},
+					{ProductType: ProductTypeAccount, Key: "TG", Text: "Tagesgeld PLUS-Konto", Count: 1, Value: 500},
+					{ProductType: ProductTypeCard, Key: "CARD", Text: "Card", Count: 1, Value: -20.75},
+					{ProductType: ProductTypeDepot, Key: "DEPOT", Text: "Depot", Count: 1, Value: 1000.5},
+				},
+			},
+		},
+		{
+			name:    "malformed balance",
+			values:  []ReportBalance{balance(ProductTypeAccount, `{"balanceEUR":{"value":"x"}}`)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := AllBalancesResponse{Values: tt.values}
+
+			got, err := r.NetWorth()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
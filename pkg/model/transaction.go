@@ -0,0 +1,32 @@
+package model
+
+type TransactionsResponse struct {
+	Paging Paging        `json:"paging"`
+	Values []Transaction `json:"values"`
+}
+
+type TransactionParty struct {
+	HolderName string `json:"holderName"`
+	Iban       string `json:"iban,omitempty"`
+	Bic        string `json:"bic,omitempty"`
+}
+
+type Transaction struct {
+	Reference             string            `json:"reference"`
+	BookingStatus         string            `json:"bookingStatus"`
+	BookingDate           string            `json:"bookingDate"`
+	ValutaDate            string            `json:"valutaDate"`
+	Amount                Amount            `json:"amount"`
+	Remitter              *TransactionParty `json:"remitter,omitempty"`
+	Deptor                *TransactionParty `json:"deptor,omitempty"`
+	Creditor              *TransactionParty `json:"creditor,omitempty"`
+	DirectDebitCreditorId string            `json:"directDebitCreditorId,omitempty"`
+	DirectDebitMandateId  string            `json:"directDebitMandateId,omitempty"`
+	EndToEndReference     string            `json:"endToEndReference,omitempty"`
+	NewTransaction        bool              `json:"newTransaction"`
+	RemittanceInfo        string            `json:"remittanceInfo"`
+	TransactionType       struct {
+		Key  string `json:"key"`
+		Text string `json:"text"`
+	} `json:"transactionType"`
+}